@@ -0,0 +1,478 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/constellation39/openapi-to-mcp/core"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const usage = `Usage: openapi-to-mcp [-config file] <command> [flags]
+
+Commands:
+  serve                       run the MCP server (default)
+  validate                    load the spec and report unsupported features per operation
+  list-tools                  print the generated tools
+  inspect <tool>              show how an operation tool maps to its HTTP request
+  call <tool> -args <json>    call a tool once and print the result
+  manifest [-o file]          write the tool definitions as a JSON manifest
+  manifest -check <file>      compare the tools with a golden manifest
+  diff <old> <new>            compare two manifests and report breaking changes
+
+Global flags:
+`
+
+// run 解析全局参数并执行子命令，未指定子命令时运行服务
+func run(args []string) error {
+	fs := flag.NewFlagSet("openapi-to-mcp", flag.ContinueOnError)
+	configPath := fs.String("config", core.LoadEnv("CONFIG_FILE", ""), "configuration file (YAML, JSON or TOML)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	cmd, rest := "serve", fs.Args()
+	if len(rest) > 0 {
+		cmd, rest = rest[0], rest[1:]
+	}
+	if cmd == "help" {
+		fs.SetOutput(os.Stdout)
+		fs.Usage()
+		return nil
+	}
+
+	if cmd == "diff" {
+		return cmdDiff(rest)
+	}
+
+	cfg, err := core.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "serve":
+		a, err := newApp(cfg, os.Stdout)
+		if err != nil {
+			return err
+		}
+		return a.serve()
+	case "validate":
+		return cmdValidate(cfg, rest)
+	case "list-tools":
+		return cmdListTools(cfg, rest)
+	case "inspect":
+		return cmdInspect(cfg, rest)
+	case "call":
+		return cmdCall(cfg, rest)
+	case "manifest":
+		return cmdManifest(cfg, rest)
+	}
+	return fmt.Errorf("unknown command %q, see openapi-to-mcp help", cmd)
+}
+
+// parseNamed 解析形如 <name> [flags] 或 [flags] <name> 的参数
+func parseNamed(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() == 0 {
+		return "", fmt.Errorf("%s: missing tool name", fs.Name())
+	}
+	name := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("%s: unexpected arguments %v", fs.Name(), fs.Args())
+	}
+	return name, nil
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// connect 创建服务并通过进程内客户端连接，工具调用与 serve 经过相同的中间件与 hooks
+func (a *app) connect(ctx context.Context) (*client.Client, error) {
+	mcpServer, err := a.newServer()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewInProcessClient(mcpServer)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Start(ctx); err != nil {
+		return nil, err
+	}
+	req := mcp.InitializeRequest{}
+	req.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	req.Params.ClientInfo = mcp.Implementation{Name: core.ServerName + "-cli", Version: core.ServerVersion}
+	if _, err := c.Initialize(ctx, req); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func cmdValidate(cfg *core.Config, args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	doc, toolOpts, err := a.loadSpec()
+	if err != nil {
+		return err
+	}
+	issues := core.ValidateOpenAPI(cfg.OpenAPI.BaseURL, doc, toolOpts...)
+	// 实际注册一次，覆盖 workflow、提示模板等只在注册时检查的配置
+	if err := a.register(server.NewMCPServer(core.ServerName, core.ServerVersion), doc, toolOpts); err != nil {
+		issues = append(issues, core.Issue{Severity: core.SeverityError, Message: err.Error()})
+	}
+
+	errorCount := 0
+	for _, is := range issues {
+		if is.Severity == core.SeverityError {
+			errorCount++
+		}
+	}
+	switch *format {
+	case "json":
+		if err := printJSON(os.Stdout, map[string]any{"issues": issues, "errors": errorCount, "warnings": len(issues) - errorCount}); err != nil {
+			return err
+		}
+	case "text":
+		for _, is := range issues {
+			where := "-"
+			if is.Tool != "" {
+				where = fmt.Sprintf("%s (%s %s)", is.Tool, is.Method, is.Path)
+			}
+			fmt.Printf("%-8s %s: %s\n", is.Severity, where, is.Message)
+		}
+		fmt.Printf("%d errors, %d warnings\n", errorCount, len(issues)-errorCount)
+	default:
+		return fmt.Errorf("validate: unknown format %q", *format)
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("validation failed with %d errors", errorCount)
+	}
+	return nil
+}
+
+func cmdListTools(cfg *core.Config, args []string) error {
+	fs := flag.NewFlagSet("list-tools", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	a, err := newApp(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		return printJSON(os.Stdout, res.Tools)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tHINTS\tARGUMENTS\tDESCRIPTION")
+		for _, t := range res.Tools {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Name, toolHints(t), toolArguments(t), firstLine(t.Description, 60))
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("list-tools: unknown format %q", *format)
+}
+
+// toolHints 以缩写列出注解：ro 只读、destructive 破坏性、idempotent 幂等
+func toolHints(t mcp.Tool) string {
+	var hints []string
+	a := t.Annotations
+	if a.ReadOnlyHint != nil && *a.ReadOnlyHint {
+		hints = append(hints, "ro")
+	}
+	if a.DestructiveHint != nil && *a.DestructiveHint {
+		hints = append(hints, "destructive")
+	}
+	if a.IdempotentHint != nil && *a.IdempotentHint {
+		hints = append(hints, "idempotent")
+	}
+	if len(hints) == 0 {
+		return "-"
+	}
+	return strings.Join(hints, ",")
+}
+
+// toolArguments 列出参数名，必填参数带 * 后缀
+func toolArguments(t mcp.Tool) string {
+	var schema struct {
+		Properties map[string]any `json:"properties"`
+		Required   []string       `json:"required"`
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "?"
+	}
+	var raw struct {
+		InputSchema json.RawMessage `json:"inputSchema"`
+	}
+	if json.Unmarshal(b, &raw) != nil || json.Unmarshal(raw.InputSchema, &schema) != nil {
+		return "?"
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		if slices.Contains(schema.Required, name) {
+			name += "*"
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
+
+func firstLine(s string, max int) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-3]) + "..."
+	}
+	return s
+}
+
+func cmdInspect(cfg *core.Config, args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	name, err := parseNamed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	doc, toolOpts, err := a.loadSpec()
+	if err != nil {
+		return err
+	}
+	mappings, err := core.InspectTools(cfg.OpenAPI.BaseURL, cfg.Upstream.Headers, doc, toolOpts...)
+	if err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		if m.Name == name {
+			return printJSON(os.Stdout, m)
+		}
+	}
+	return fmt.Errorf("inspect: no operation tool named %q, see list-tools", name)
+}
+
+func cmdCall(cfg *core.Config, args []string) error {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	argsJSON := fs.String("args", "{}", "tool arguments as a JSON object, or @file to read them from a file")
+	raw := fs.Bool("json", false, "print the whole result as JSON")
+	name, err := parseNamed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	input := []byte(*argsJSON)
+	if path, ok := strings.CutPrefix(*argsJSON, "@"); ok {
+		if input, err = os.ReadFile(path); err != nil {
+			return err
+		}
+	}
+	var arguments map[string]any
+	if err := json.Unmarshal(input, &arguments); err != nil {
+		return fmt.Errorf("call: -args must be a JSON object: %w", err)
+	}
+
+	ctx := context.Background()
+	a, err := newApp(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = arguments
+	res, err := c.CallTool(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if *raw {
+		if err := printJSON(os.Stdout, res); err != nil {
+			return err
+		}
+	} else {
+		for _, content := range res.Content {
+			if tc, ok := content.(mcp.TextContent); ok {
+				fmt.Println(tc.Text)
+				continue
+			}
+			if err := printJSON(os.Stdout, content); err != nil {
+				return err
+			}
+		}
+	}
+	if res.IsError {
+		return fmt.Errorf("tool %s returned an error", name)
+	}
+	return nil
+}
+
+func cmdManifest(cfg *core.Config, args []string) error {
+	fs := flag.NewFlagSet("manifest", flag.ContinueOnError)
+	out := fs.String("o", "", "write the manifest to this file instead of standard output")
+	check := fs.String("check", "", "golden manifest to compare with, exits 1 on any difference")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	mcpServer, err := a.newServer()
+	if err != nil {
+		return err
+	}
+	m, err := core.NewManifest(mcpServer)
+	if err != nil {
+		return err
+	}
+
+	if *check != "" {
+		golden, err := core.LoadManifest(*check)
+		if err != nil {
+			return err
+		}
+		changes := core.DiffManifests(golden, m)
+		printChanges(changes)
+		if len(changes) > 0 {
+			return fmt.Errorf("tools differ from %s, regenerate it with manifest -o %s", *check, *check)
+		}
+		return nil
+	}
+	if *out == "" {
+		return core.WriteManifest(os.Stdout, m)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := core.WriteManifest(f, m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func cmdDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	failOn := fs.String("fail-on", core.ChangeBreaking, "exit 1 on: breaking, any or none")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("diff: expected two manifest files")
+	}
+	old, err := core.LoadManifest(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cur, err := core.LoadManifest(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	changes := core.DiffManifests(old, cur)
+
+	switch *format {
+	case "json":
+		if changes == nil {
+			changes = []core.ManifestChange{}
+		}
+		if err := printJSON(os.Stdout, changes); err != nil {
+			return err
+		}
+	case "text":
+		printChanges(changes)
+	default:
+		return fmt.Errorf("diff: unknown format %q", *format)
+	}
+
+	breaking := 0
+	for _, c := range changes {
+		if c.Kind == core.ChangeBreaking {
+			breaking++
+		}
+	}
+	switch *failOn {
+	case core.ChangeBreaking:
+		if breaking > 0 {
+			return fmt.Errorf("%d breaking changes", breaking)
+		}
+	case "any":
+		if len(changes) > 0 {
+			return fmt.Errorf("%d changes", len(changes))
+		}
+	case "none":
+	default:
+		return fmt.Errorf("diff: unknown -fail-on value %q", *failOn)
+	}
+	return nil
+}
+
+func printChanges(changes []core.ManifestChange) {
+	breaking := 0
+	for _, c := range changes {
+		if c.Kind == core.ChangeBreaking {
+			breaking++
+		}
+		fmt.Printf("%-10s %s: %s\n", c.Kind, c.Tool, c.Message)
+	}
+	fmt.Printf("%d breaking, %d compatible changes\n", breaking, len(changes)-breaking)
+}
@@ -5,14 +5,16 @@
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/constellation39/openapi-to-mcp/core/session"
 	"io"
 	"maps"
 	"net/http"
 	neturl "net/url"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/constellation39/openapi-to-mcp/core/session"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
@@ -59,126 +61,330 @@ func parsePathTmpl(tmpl string) []string {
 	return out
 }
 
-func NewToolHandlerFromOp(
-	baseURL, pathTmpl, method string,
-	paramIn map[string]string,
-	hasBody bool,
-	extraHeaders map[string]string,
-) func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-
-	pathVars := parsePathTmpl(pathTmpl)
+// opCaller 根据调用参数构造并发送上游请求，供工具与资源模板共用
+type opCaller struct {
+	baseURL, pathTmpl, method string
+	paramIn                   map[string]string
+	hasBody                   bool
+	extraHeaders              map[string]string
+	pathVars                  []string
+	o                         *options
+}
 
-	return func(ctx context.Context, call mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// preparedRequest 是与上游地址无关的请求内容
+type preparedRequest struct {
+	relURL string
+	body   []byte
+	header http.Header
+	args   map[string]any // 转换表达式中的 args
+}
 
-		cli := http.DefaultClient
+func newOpCaller(baseURL, pathTmpl, method string, paramIn map[string]string,
+	hasBody bool, extraHeaders map[string]string, o *options) *opCaller {
+	return &opCaller{
+		baseURL:      baseURL,
+		pathTmpl:     pathTmpl,
+		method:       method,
+		paramIn:      paramIn,
+		hasBody:      hasBody,
+		extraHeaders: extraHeaders,
+		pathVars:     parsePathTmpl(pathTmpl),
+		o:            o,
+	}
+}
 
-		if LoadEnv("USE_COOKIE", "true") == "true" {
-			if cs := server.ClientSessionFromContext(ctx); cs != nil {
-				if st, ok := session.Instance().GetSession(cs.SessionID()); ok && st.Client != nil {
-					cli = st.Client
-				}
+// client 返回发送请求的 Client，启用 Cookie 时在注入的 Client 上使用会话自己的 CookieJar
+func (c *opCaller) client(ctx context.Context) *http.Client {
+	if c.o.cookies && c.o.sessions != nil {
+		if cs := server.ClientSessionFromContext(ctx); cs != nil {
+			if st, ok := c.o.sessions.GetSession(cs.SessionID()); ok && st.Client != nil && st.Client.Jar != nil {
+				cli := *c.o.client
+				cli.Jar = st.Client.Jar
+				return &cli
 			}
 		}
+	}
+	return c.o.client
+}
 
-		pathVals := make(map[string]any, len(pathVars))
-		queryVals := neturl.Values{}
-		headerVals := http.Header{}
-		var bodyVal any
-
-		if raw, ok := call.Params.Arguments.(map[string]any); ok {
-			for k, v := range raw {
-				switch paramIn[k] {
-				case "path":
-					pathVals[k] = v
-				case "query", "":
-					queryVals.Add(k, fmt.Sprintf("%v", v))
-				case "header":
-					headerVals.Add(k, fmt.Sprintf("%v", v))
-				case "cookie":
-					headerVals.Add("Cookie", fmt.Sprintf("%s=%v", k, v))
-				case "body":
-					bodyVal = v
-				}
-			}
+// formatValue 将参数值格式化为字符串，JSON 数字 (float64) 不使用科学计数法
+func formatValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func (c *opCaller) prepare(raw map[string]any) (*preparedRequest, error) {
+	pathVals := make(map[string]any, len(c.pathVars))
+	queryVals := neturl.Values{}
+	headerVals := http.Header{}
+	var bodyVal any
+
+	for k, v := range raw {
+		switch c.paramIn[k] {
+		case "path":
+			pathVals[k] = v
+		case "query", "":
+			queryVals.Add(k, formatValue(v))
+		case "header":
+			headerVals.Add(k, formatValue(v))
+		case "cookie":
+			headerVals.Add("Cookie", k+"="+formatValue(v))
+		case "body":
+			bodyVal = v
 		}
+	}
 
-		var sb strings.Builder
-		sb.Grow(len(baseURL) + len(pathTmpl) + 32)
-		sb.WriteString(baseURL)
+	var sb strings.Builder
+	sb.Grow(len(c.pathTmpl) + 32)
 
-		cur := pathTmpl
-		for _, v := range pathVars {
-			ph := "{" + v + "}"
-			idx := strings.Index(cur, ph)
-			sb.WriteString(cur[:idx])
-			sb.WriteString(fmt.Sprintf("%v", pathVals[v]))
-			cur = cur[idx+len(ph):]
+	cur := c.pathTmpl
+	for _, v := range c.pathVars {
+		ph := "{" + v + "}"
+		idx := strings.Index(cur, ph)
+		sb.WriteString(cur[:idx])
+		if val := pathVals[v]; val != nil {
+			sb.WriteString(formatValue(val))
+		} else {
+			// 只有 dry-run 会缺少路径参数，保留占位符
+			sb.WriteString(ph)
 		}
-		sb.WriteString(cur)
+		cur = cur[idx+len(ph):]
+	}
+	sb.WriteString(cur)
 
-		if qs := queryVals.Encode(); qs != "" {
-			if strings.ContainsRune(sb.String(), '?') {
-				sb.WriteByte('&')
-			} else {
-				sb.WriteByte('?')
-			}
-			sb.WriteString(qs)
+	if qs := queryVals.Encode(); qs != "" {
+		if strings.ContainsRune(sb.String(), '?') {
+			sb.WriteByte('&')
+		} else {
+			sb.WriteByte('?')
 		}
-		finalURL := sb.String()
+		sb.WriteString(qs)
+	}
 
-		var bodyReader io.Reader
-		if hasBody && bodyVal != nil {
-			b, err := json.Marshal(bodyVal)
-			if err != nil {
-				return mcp.NewToolResultText("marshal body: " + err.Error()), nil
-			}
-			bodyReader = bytes.NewReader(b)
+	p := &preparedRequest{relURL: sb.String(), header: headerVals, args: raw}
+	if c.hasBody && bodyVal != nil {
+		b, err := json.Marshal(bodyVal)
+		if err != nil {
+			return nil, fmt.Errorf("marshal body: %w", err)
 		}
+		p.body = b
+	}
+	return p, nil
+}
 
-		req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), finalURL, bodyReader)
-		if err != nil {
-			return mcp.NewToolResultText("new request: " + err.Error()), nil
+func (c *opCaller) newRequest(ctx context.Context, base string, p *preparedRequest) (*http.Request, error) {
+	var bodyReader io.Reader
+	if p.body != nil {
+		bodyReader = bytes.NewReader(p.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(c.method), base+p.relURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if bodyReader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.extraHeaders {
+		req.Header.Set(k, v)
+	}
+	for k, vs := range p.header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
 		}
-		if bodyReader != nil {
-			req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// buildRequest 构造请求并应用请求转换，返回转换后的请求体
+func (c *opCaller) buildRequest(ctx context.Context, base string, p *preparedRequest) (*http.Request, []byte, error) {
+	req, err := c.newRequest(ctx, base, p)
+	if err != nil || c.o.transform == nil {
+		return req, p.body, err
+	}
+	body, err := c.o.transform.applyRequest(ctx, c.o, req, p.args, p.body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, body, nil
+}
+
+// displayBase 是展示给用户的上游地址，启用上游池时取第一个
+func (c *opCaller) displayBase() string {
+	if c.o.pool != nil {
+		return c.o.pool.URLs()[0]
+	}
+	return c.baseURL
+}
+
+// send 构造并发送请求。凭据与 BeforeRequest 只执行一次，它们的错误不计入上游健康状态；
+// 请求转换在每次尝试时针对实际的上游地址执行，签名等内容与发送的请求一致
+func (c *opCaller) send(ctx context.Context, p *preparedRequest) (*http.Response, error) {
+	base := c.displayBase()
+	req, err := c.newRequest(ctx, base, p)
+	if err != nil {
+		return nil, err
+	}
+	if c.o.credentials != nil {
+		if err := c.o.credentials(ctx, req); err != nil {
+			return nil, fmt.Errorf("credentials: %w", err)
 		}
-		for k, v := range extraHeaders {
-			req.Header.Set(k, v)
+	}
+	if h := c.o.hooks.BeforeRequest; h != nil {
+		if err := h(ctx, c.o.toolName, req); err != nil {
+			return nil, err
 		}
-		for k, vs := range headerVals {
-			for _, v := range vs {
-				req.Header.Add(k, v)
+	}
+
+	cli := c.client(ctx)
+	do := func(r *http.Request) (*http.Response, error) {
+		if c.o.transform != nil {
+			if _, err := c.o.transform.applyRequest(ctx, c.o, r, p.args, p.body); err != nil {
+				return nil, err
 			}
 		}
+		return cli.Do(r)
+	}
+	var resp *http.Response
+	if c.o.pool != nil {
+		resp, err = c.o.pool.Do(ctx, c.method, func(target string) (*http.Response, error) {
+			r, err := retarget(req, base, target)
+			if err != nil {
+				return nil, err
+			}
+			return do(r)
+		})
+	} else {
+		resp, err = do(req)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		resp, err := cli.Do(req)
-		if err != nil {
-			return mcp.NewToolResultText("http do: " + err.Error()), nil
+	if h := c.o.hooks.AfterResponse; h != nil {
+		if err := h(ctx, c.o.toolName, resp); err != nil {
+			resp.Body.Close()
+			return nil, err
 		}
-		defer resp.Body.Close()
+	}
+	if c.o.transform != nil {
+		if err := c.o.transform.applyResponse(ctx, c.o, resp, p.args); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
 
-		rb, _ := io.ReadAll(resp.Body)
-		return mcp.NewToolResultText(string(rb)), nil
+// retarget 复制请求，把地址开头的 from 替换为 to，请求体通过 GetBody 重新获取
+func retarget(req *http.Request, from, to string) (*http.Request, error) {
+	rest, ok := strings.CutPrefix(req.URL.String(), from)
+	if !ok {
+		return nil, fmt.Errorf("request url %s is not under upstream %s", req.URL, from)
+	}
+	u, err := neturl.Parse(to + rest)
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.URL, r.Host = u, ""
+	if req.GetBody != nil {
+		if r.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// resolveArgs 填充参数默认值与固定值，缺少必填参数时通过 elicitation 向用户收集
+func (c *opCaller) resolveArgs(ctx context.Context, tool string, raw map[string]any) (map[string]any, *mcp.CallToolResult) {
+	raw = applyParamValues(raw, c.o.paramDefaults, c.o.paramFixed)
+	if missing := missingArgs(raw, c.o.requiredArgs); len(missing) > 0 {
+		return elicitMissingArgs(ctx, tool, raw, missing)
 	}
+	return raw, nil
 }
 
-func lookupMethodOperation(item *v3high.PathItem) (string, *v3high.Operation) {
-	m := map[string]*v3high.Operation{
-		"Get":     item.Get,
-		"Put":     item.Put,
-		"Post":    item.Post,
-		"Delete":  item.Delete,
-		"Options": item.Options,
-		"Head":    item.Head,
-		"Patch":   item.Patch,
-		"Trace":   item.Trace,
+func (c *opCaller) isDryRun(reserved map[string]any) bool {
+	return c.o.dryRun || reservedBool(reserved, ArgDryRun)
+}
+
+// call 构造请求后依次处理 dry-run、mock 与确认，再发送请求，工具与 workflow 步骤共用。
+// 确认时展示的是转换后真正发送的请求。返回的结果非 nil 表示请求没有发送到上游
+func (c *opCaller) call(ctx context.Context, p *preparedRequest, reserved map[string]any) (*http.Response, *mcp.CallToolResult, error) {
+	// 这里构造的请求用于演练与确认，实际发送的请求由 send 按上游重新构造
+	req, body, err := c.buildRequest(ctx, c.displayBase(), p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.isDryRun(reserved) {
+		return nil, dryRunResult(req, body), nil
+	}
+
+	if c.o.mock {
+		return nil, mockResult(c.o.operation, reserved), nil
 	}
-	for k, v := range m {
-		if v != nil {
-			return k, v
+
+	if c.o.needConfirm {
+		if res := confirmRequest(ctx, c.o.confirm.Fallback, req.Method, redactURL(req.URL), body); res != nil {
+			return nil, res, nil
 		}
 	}
-	return "", nil
+
+	resp, err := c.send(ctx, p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("http do: %w", err)
+	}
+	return resp, nil, nil
+}
+
+func NewToolHandlerFromOp(
+	baseURL, pathTmpl, method string,
+	paramIn map[string]string,
+	hasBody bool,
+	extraHeaders map[string]string,
+	opts ...Option,
+) func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+	o := newOptions(opts)
+	c := newOpCaller(baseURL, pathTmpl, method, paramIn, hasBody, extraHeaders, o)
+
+	return func(ctx context.Context, call mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		args, _ := call.Params.Arguments.(map[string]any)
+		reserved, sent := popReservedArgs(args, c.paramIn)
+		raw, res := c.resolveArgs(ctx, call.Params.Name, sent)
+		if res != nil {
+			return res, nil
+		}
+
+		p, err := c.prepare(raw)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		resp, res, err := c.call(ctx, p, reserved)
+		if err != nil {
+			return mcp.NewToolResultText(err.Error()), nil
+		}
+		if res != nil {
+			return res, nil
+		}
+		defer resp.Body.Close()
+
+		rb, _ := io.ReadAll(resp.Body)
+		res = mcp.NewToolResultText(string(rb))
+		res.IsError = resp.StatusCode >= http.StatusBadRequest
+		if len(o.responseLinks) > 0 {
+			// $request.* 只使用模型传入的参数，x-mcp-fixed 等隐藏的值不能出现在结果中
+			lc := &linkContext{args: sent, method: method, resp: resp, respRaw: rb}
+			if resp.Request != nil {
+				// 建议的后续调用会返回给模型，$url 中不能带凭据
+				lc.url = redactURL(resp.Request.URL)
+			}
+			res = withNextActions(res, nextActions(o.responseLinks, lc))
+		}
+		return res, nil
+	}
 }
 
 // mergeParameters 保持顺序并避免不必要的复制
@@ -216,13 +422,14 @@ func mergeParameters(pathParams, opParams []*v3high.Parameter) []*v3high.Paramet
 	return out
 }
 
-func convertSchemaToMCP(name string, s *v3base.Schema, required bool) mcp.ToolOption {
+func convertSchemaToMCP(name string, s *v3base.Schema, required bool, extra ...mcp.PropertyOption) mcp.ToolOption {
 	if s == nil {
 		return nil
 	}
 	t := firstType(s.Type)
 
-	common := make([]mcp.PropertyOption, 0, 6)
+	common := make([]mcp.PropertyOption, 0, 6+len(extra))
+	common = append(common, extra...)
 	if required {
 		common = append(common, mcp.Required())
 	}
@@ -274,13 +481,6 @@ func convertSchemaToMCP(name string, s *v3base.Schema, required bool) mcp.ToolOp
 	return mcp.WithString(name, common...)
 }
 
-func pickBaseURLFromDoc(doc v3high.Document) string {
-	if servers := doc.Servers; len(servers) > 0 {
-		return servers[0].URL
-	}
-	return ""
-}
-
 func collectBasicAuthSchemes(doc v3high.Document) map[string]struct{} {
 	basicSchemes := map[string]struct{}{}
 	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
@@ -316,49 +516,155 @@ func needBasicAuth(op *v3high.Operation, doc v3high.Document, basicSchemes map[s
 	return check(doc.Security)
 }
 
+// AddToolFromOpenAPI 将文档转换为工具注册到 mcpServer，未指定 WithSessions 时使用全局的 session.Instance()
 func AddToolFromOpenAPI(
 	mcpServer *server.MCPServer,
 	baseURL string,
 	extraHeaders map[string]string,
-	v3Model *libopenapi.DocumentModel[v3high.Document]) error {
+	v3Model *libopenapi.DocumentModel[v3high.Document],
+	opts ...Option) error {
 
-	doc := v3Model.Model
-	if baseURL == "" {
-		baseURL = pickBaseURLFromDoc(doc)
-	}
+	opts = append([]Option{WithSessions(session.Instance())}, opts...)
+	opts = append(opts, WithBaseURL(baseURL), WithHeaders(extraHeaders))
+	return NewConverter(v3Model, opts...).Register(mcpServer)
+}
 
+// buildOperationTools 为通过过滤规则的操作构建工具与处理函数，不注册到服务
+func buildOperationTools(baseURL string, extraHeaders map[string]string, doc v3high.Document, opts []Option) ([]operationTool, error) {
+	o := newOptions(opts)
 	basicAuthSchemes := collectBasicAuthSchemes(doc)
 
-	for it := doc.Paths.PathItems.First(); it != nil; it = it.Next() {
-		path := it.Key()
-		item := it.Value()
-		method, op := lookupMethodOperation(item)
-		if op == nil {
-			continue
+	filter, err := o.filter.compile()
+	if err != nil {
+		return nil, err
+	}
+	var included, excluded int
+	var tools []operationTool
+	var targets map[string]string
+	if o.links {
+		if targets, err = linkTargets(doc, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	names := map[string]bool{}
+	err = eachOperation(doc, func(path, method string, item *v3high.PathItem, op *v3high.Operation) error {
+		name := toolName(path, method, op)
+		names[name] = true
+		if ok, reason := filter.match(path, method, name, op); !ok {
+			excluded++
+			o.logger.Printf("exclude tool %s (%s %s): %s", name, method, path, reason)
+			return nil
 		}
+		included++
 
 		needAuth := needBasicAuth(op, doc, basicAuthSchemes)
 		opHeaders := extraHeaders
 		if needAuth {
-			opHeaders = maps.Clone(extraHeaders)
-			authorizationValue := LoadEnv("AUTHORIZATION_HEADERS", "")
-			if authorizationValue == "" {
-				tip := "This interface (%s) requires HTTP Basic authentication. Write AUTHORIZATION_HEADERS='{\"Authorization\": \"Basic xxxx\"}' in the environment variables."
-				return fmt.Errorf(fmt.Sprintf(tip, path))
+			opHeaders = make(map[string]string, len(extraHeaders)+1)
+			maps.Copy(opHeaders, extraHeaders)
+			if o.authorization == "" {
+				tip := "This interface (%s) requires HTTP Basic authentication. Set auth.authorization in the config file or AUTHORIZATION_HEADERS=\"Basic xxxx\" in the environment variables."
+				return fmt.Errorf(tip, path)
 			}
-			opHeaders["Authorization"] = authorizationValue
+			opHeaders["Authorization"] = o.authorization
 			tip := "This interface requires HTTP Basic authentication. MCP tool has been processed remotely."
 			op.Description = op.Description + tip
 		}
 
-		tool := buildOneTool(path, method, op, item)
+		tool := buildOneTool(path, method, op, item, o)
+		var links map[string][]responseLink
+		if o.links {
+			links = collectLinks(op, targets, o)
+			tool.Description += linksDescription(links)
+		}
 
+		opBaseURL := baseURL
+		if opBaseURL == "" {
+			u, err := operationBaseURL(doc, item, op, o)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			opBaseURL = u
+		}
+		transform, err := collectTransforms(name, extNode(op.Extensions, extTransform), o.transforms)
+		if err != nil {
+			return err
+		}
+		params := mergeParameters(item.Parameters, op.Parameters)
+		defaults, fixed := collectParamValues(params)
 		paramIn, hasBody := collectParamLocation(item, op)
-		h := NewToolHandlerFromOp(baseURL, path, method, paramIn, hasBody, opHeaders)
+		handlerOpts := append(slices.Clip(opts),
+			withToolName(name),
+			withParamValues(defaults, fixed),
+			withNeedConfirm(o.confirm.needConfirm(method, op, o.destructiveMethods)),
+			withRequiredArgs(collectRequiredArgs(params, op, hasBody)),
+			withOperation(op),
+			withResponseLinks(links),
+			withTransform(transform),
+		)
+		if len(op.Servers) > 0 || len(item.Servers) > 0 {
+			// 自带 servers 的操作不参与上游池
+			handlerOpts = append(handlerOpts, WithUpstreamPool(nil))
+		}
 
-		mcpServer.AddTool(tool, h)
+		h := NewToolHandlerFromOp(opBaseURL, path, method, paramIn, hasBody, opHeaders, handlerOpts...)
+		caller := newOpCaller(opBaseURL, path, method, paramIn, hasBody, opHeaders, newOptions(handlerOpts))
+
+		o.logger.Printf("include tool %s (%s %s)", name, method, path)
+		tools = append(tools, operationTool{
+			name: name, path: path, method: method, item: item, op: op, params: params,
+			tool: tool, handler: h, caller: caller, opts: handlerOpts,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for name := range o.transforms {
+		if !names[name] {
+			return nil, fmt.Errorf("transform for unknown operation %q", name)
+		}
 	}
 
+	o.logger.Printf("openapi tools: %d included, %d excluded", included, excluded)
+	return tools, nil
+}
+
+// operationTool 是单个操作生成的工具，按 ToolMode 直接注册或经由元工具调用
+type operationTool struct {
+	name, path, method string
+	item               *v3high.PathItem
+	op                 *v3high.Operation
+	params             []*v3high.Parameter
+	tool               mcp.Tool
+	handler            server.ToolHandlerFunc
+	caller             *opCaller
+	opts               []Option // 构建处理函数时使用的选项，资源模板沿用
+}
+
+// invoke 以给定参数调用操作，供元工具、分组工具与批量工具转发
+func (t operationTool) invoke(ctx context.Context, args map[string]any) (*mcp.CallToolResult, error) {
+	call := mcp.CallToolRequest{}
+	call.Params.Name = t.name
+	call.Params.Arguments = args
+	return t.handler(ctx, call)
+}
+
+// eachOperation 按文档顺序遍历全部操作，method 为大写
+func eachOperation(doc v3high.Document, fn func(path, method string, item *v3high.PathItem, op *v3high.Operation) error) error {
+	if doc.Paths == nil || doc.Paths.PathItems == nil {
+		return nil
+	}
+	for it := doc.Paths.PathItems.First(); it != nil; it = it.Next() {
+		path := it.Key()
+		item := it.Value()
+		for opIt := item.GetOperations().First(); opIt != nil; opIt = opIt.Next() {
+			if err := fn(path, strings.ToUpper(opIt.Key()), item, opIt.Value()); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -383,20 +689,20 @@ func collectParamLocation(item *v3high.PathItem, op *v3high.Operation) (map[stri
 }
 
 func buildOneTool(path, method string,
-	op *v3high.Operation, item *v3high.PathItem) mcp.Tool {
+	op *v3high.Operation, item *v3high.PathItem, o *options) mcp.Tool {
 
-	name := op.OperationId
-	if name == "" {
-		name = sanitizeToolName(fmt.Sprintf("%s_%s", method, path))
-	}
-	desc := coalesce(op.Description, op.Summary,
+	name := toolName(path, method, op)
+	desc := coalesce(extString(op.Extensions, extDescription), op.Description, op.Summary,
 		fmt.Sprintf("%s %s", method, path))
 
-	opts := []mcp.ToolOption{mcp.WithDescription(desc)}
+	opts := []mcp.ToolOption{
+		mcp.WithDescription(desc),
+		mcp.WithToolAnnotation(toolAnnotation(method, op, o.destructiveMethods)),
+	}
 
 	params := mergeParameters(item.Parameters, op.Parameters)
 	for _, p := range params {
-		if p == nil {
+		if p == nil || paramHidden(p) {
 			continue
 		}
 		if opt := convertParameter(p); opt != nil {
@@ -412,17 +718,39 @@ func buildOneTool(path, method string,
 		}
 	}
 
+	paramIn, _ := collectParamLocation(item, op)
+	opts = append(opts, reservedArgOptions(paramIn, o)...)
 	return mcp.NewTool(name, opts...)
 }
 
+func toolName(path, method string, op *v3high.Operation) string {
+	if name := extString(op.Extensions, extName); name != "" {
+		return name
+	}
+	if op.OperationId != "" {
+		return op.OperationId
+	}
+	return sanitizeToolName(fmt.Sprintf("%s_%s", method, path))
+}
+
 func convertParameter(p *v3high.Parameter) mcp.ToolOption {
 	if p.Schema == nil {
 		return nil
 	}
 	required := boolVal(p.Required)
+	if def, ok := extValue(p.Extensions, extDefault); ok {
+		// 有默认值时模型可以省略该参数
+		return convertSchemaToMCP(p.Name, p.Schema.Schema(), false, withDefault(def)) // 始终只有 1 个
+	}
 	return convertSchemaToMCP(p.Name, p.Schema.Schema(), required) // 始终只有 1 个
 }
 
+func withDefault(v any) mcp.PropertyOption {
+	return func(m map[string]any) {
+		m["default"] = v
+	}
+}
+
 func coalesce(vals ...string) string {
 	for _, v := range vals {
 		if v != "" {
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// extAnnotations 覆盖按 HTTP 语义推导出的工具注解，
+// 例如 x-mcp-annotations: {destructiveHint: false, title: "Archive pet"}
+const extAnnotations = "x-mcp-annotations"
+
+var defaultDestructiveMethods = []string{http.MethodDelete}
+
+func isSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// toolAnnotation 根据 RFC 9110 的方法语义生成 MCP 工具注解
+func toolAnnotation(method string, op *v3high.Operation, destructiveMethods []string) mcp.ToolAnnotation {
+	safe := isSafeMethod(method)
+	ann := mcp.ToolAnnotation{
+		Title:           op.Summary,
+		ReadOnlyHint:    mcp.ToBoolPtr(safe),
+		DestructiveHint: mcp.ToBoolPtr(!safe && containsFold(destructiveMethods, method)),
+		IdempotentHint:  mcp.ToBoolPtr(isIdempotentMethod(method)),
+		OpenWorldHint:   mcp.ToBoolPtr(true),
+	}
+
+	v, ok := extValue(op.Extensions, extAnnotations)
+	if !ok {
+		return ann
+	}
+	m, _ := v.(map[string]any)
+	if title, ok := m["title"].(string); ok {
+		ann.Title = title
+	}
+	for key, dst := range map[string]**bool{
+		"readOnlyHint":    &ann.ReadOnlyHint,
+		"destructiveHint": &ann.DestructiveHint,
+		"idempotentHint":  &ann.IdempotentHint,
+		"openWorldHint":   &ann.OpenWorldHint,
+	} {
+		if b, ok := m[key].(bool); ok {
+			*dst = mcp.ToBoolPtr(b)
+		}
+	}
+	return ann
+}
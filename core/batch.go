@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	BatchToolName = "batch_operation"
+	batchMaxItems = 100
+)
+
+type batchItemResult struct {
+	Index  int    `json:"index"`
+	Status int    `json:"status,omitempty"` // 上游状态码，dry-run 时为 0
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// addBatchTool 注册批量调用工具：以多组参数并发调用同一个操作，每一项都经过正常的处理流程与限流
+func addBatchTool(mcpServer ToolRegistry, tools []operationTool, concurrency int, limiter *RateLimitMiddleware) {
+	byName := make(map[string]operationTool, len(tools))
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		byName[t.name] = t
+		names = append(names, t.name)
+	}
+
+	mcpServer.AddTool(mcp.NewTool(BatchToolName,
+		mcp.WithDescription(fmt.Sprintf("Call one operation with up to %d argument sets, %d at a time. "+
+			"Returns the result or error of every item in input order.", batchMaxItems, concurrency)),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			ReadOnlyHint:    mcp.ToBoolPtr(false),
+			DestructiveHint: mcp.ToBoolPtr(true),
+			IdempotentHint:  mcp.ToBoolPtr(false),
+			OpenWorldHint:   mcp.ToBoolPtr(true),
+		}),
+		mcp.WithString("operation", mcp.Required(), mcp.Enum(names...), mcp.Description("Operation to call")),
+		mcp.WithArray("items", mcp.Required(), mcp.MinItems(1), mcp.MaxItems(batchMaxItems),
+			mcp.Items(map[string]any{"type": "object"}),
+			mcp.Description("Argument objects, one per call, matching the operation's input schema")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("operation")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		t, ok := byName[name]
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown operation %q", name)), nil
+		}
+		items, _ := request.GetArguments()["items"].([]any)
+		if len(items) == 0 || len(items) > batchMaxItems {
+			return mcp.NewToolResultError(fmt.Sprintf("items must contain 1 to %d argument objects", batchMaxItems)), nil
+		}
+
+		results := make([]batchItemResult, len(items))
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i, item := range items {
+			results[i].Index = i
+			args, ok := item.(map[string]any)
+			if !ok {
+				results[i].Error = "item is not an object"
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						results[i].Error = err.Error()
+						return
+					}
+				}
+				reserved, args := popReservedArgs(args, t.caller.paramIn)
+				status, body, err := t.exec(ctx, args, reserved)
+				results[i].Status = status
+				switch {
+				case err != nil:
+					results[i].Error = err.Error()
+				case status >= http.StatusBadRequest:
+					results[i].Error = fmt.Sprintf("upstream returned %d: %s", status, body)
+				default:
+					results[i].Result = string(body)
+				}
+			}()
+		}
+		wg.Wait()
+
+		failed := 0
+		for _, r := range results {
+			if r.Error != "" {
+				failed++
+			}
+		}
+		summary := map[string]any{
+			"operation": name,
+			"succeeded": len(results) - failed,
+			"failed":    failed,
+			"results":   results,
+		}
+		b, err := json.Marshal(summary)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructured(summary, string(b)), nil
+	})
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestBatchItemStatus(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pets/404":
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		case "/pets/500":
+			http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	t.Cleanup(up.Close)
+
+	s := server.NewMCPServer("t", "1")
+	if err := NewConverter(loadTestDoc(t, testSpec), WithBaseURL(up.URL), WithBatchTool(2)).Register(s); err != nil {
+		t.Fatal(err)
+	}
+	res := callTool(t, *s.GetTool(BatchToolName), map[string]any{
+		"operation": "getPet",
+		"items": []any{
+			map[string]any{"petId": 1},
+			map[string]any{"petId": 404},
+			map[string]any{"petId": 500},
+			map[string]any{"petId": 2, ArgDryRun: true},
+		},
+	})
+
+	var out struct {
+		Succeeded int               `json:"succeeded"`
+		Failed    int               `json:"failed"`
+		Results   []batchItemResult `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(resultText(res)), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Succeeded != 2 || out.Failed != 2 {
+		t.Errorf("succeeded = %d, failed = %d, want 2 and 2", out.Succeeded, out.Failed)
+	}
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNotFound, true},
+		{http.StatusInternalServerError, true},
+		{0, false},
+	}
+	for i, tt := range tests {
+		r := out.Results[i]
+		if r.Status != tt.status || (r.Error != "") != tt.wantErr {
+			t.Errorf("item %d: status = %d, error = %q, want status %d and error %v", i, r.Status, r.Error, tt.status, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cassette 保存上游请求与响应，用于离线回放。凭据 (包括 JSON 请求体与响应体中的字段) 在写入前已脱敏
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	RecordedAt time.Time        `json:"recordedAt"`
+	Request    CassetteRequest  `json:"request"`
+	Response   CassetteResponse `json:"response"`
+}
+
+type CassetteRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type CassetteResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+func readBody(rc io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	if rc == nil || rc == http.NoBody {
+		return nil, rc, nil
+	}
+	b, err := io.ReadAll(rc)
+	rc.Close()
+	return b, io.NopCloser(bytes.NewReader(b)), err
+}
+
+// interactionKey 忽略主机名，使录制结果在不同上游地址间通用。
+// 请求体按写入 cassette 时的规则脱敏，凭据不同的请求也能匹配
+func interactionKey(method string, u *neturl.URL, body string) string {
+	ru, err := neturl.Parse(redactURL(u))
+	if err != nil {
+		ru = u
+	}
+	return strings.ToUpper(method) + " " + ru.RequestURI() + "\n" + string(redactBody([]byte(body)))
+}
+
+// CassetteRecorder 转发请求并把每次交互追加写入 cassette 文件。
+// 文件在第一次请求时创建，每次只写入新的交互，写入后文件始终是完整的 JSON
+type CassetteRecorder struct {
+	path      string
+	next      http.RoundTripper
+	overwrite bool
+	logger    *log.Logger
+	mu        sync.Mutex
+	file      *os.File
+	size      int64 // 已写入的交互结束的位置，结尾的 cassetteTail 从这里开始
+	count     int
+}
+
+const (
+	cassetteHead = "{\n  \"interactions\": ["
+	cassetteTail = "\n  ]\n}\n"
+)
+
+// NewCassetteRecorder 创建录制器。path 已存在时返回错误，除非 overwrite 为 true。
+// 写入失败不影响请求，只记录到 logger (为 nil 时使用 log.Default())
+func NewCassetteRecorder(path string, next http.RoundTripper, overwrite bool, logger *log.Logger) (*CassetteRecorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	// 尽早暴露路径错误
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return nil, fmt.Errorf("cassette %s: %w", path, fs.ErrExist)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	return &CassetteRecorder{path: path, next: next, overwrite: overwrite, logger: logger}, nil
+}
+
+func (r *CassetteRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = body
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, body, err := readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = body
+
+	it := Interaction{
+		RecordedAt: time.Now().UTC(),
+		Request: CassetteRequest{
+			Method:  req.Method,
+			URL:     redactURL(req.URL),
+			Headers: redactHeaders(req.Header),
+			Body:    string(redactBody(reqBody)),
+		},
+		Response: CassetteResponse{
+			Status:  resp.StatusCode,
+			Headers: redactHeaders(resp.Header),
+			Body:    string(redactBody(respBody)),
+		},
+	}
+	if err := r.append(it); err != nil {
+		// 上游请求已经成功，录制失败不影响调用
+		r.logger.Printf("write cassette %s: %v", r.path, err)
+	}
+	return resp, nil
+}
+
+// append 在结尾之前写入一条交互并重写结尾
+func (r *CassetteRecorder) append(it Interaction) error {
+	b, err := json.MarshalIndent(it, "    ", "  ")
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		flag := os.O_RDWR | os.O_CREATE | os.O_EXCL
+		if r.overwrite {
+			flag = os.O_RDWR | os.O_CREATE | os.O_TRUNC
+		}
+		f, err := os.OpenFile(r.path, flag, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString(cassetteHead); err != nil {
+			f.Close()
+			return err
+		}
+		r.file, r.size = f, int64(len(cassetteHead))
+	}
+
+	var buf bytes.Buffer
+	if r.count > 0 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString("\n    ")
+	buf.Write(b)
+	entry := buf.Len()
+	buf.WriteString(cassetteTail)
+	if _, err := r.file.WriteAt(buf.Bytes(), r.size); err != nil {
+		return err
+	}
+	r.size += int64(entry)
+	r.count++
+	return nil
+}
+
+// Close 关闭 cassette 文件，之后的请求仍然转发但不再录制
+func (r *CassetteRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// CassetteReplayer 从 cassette 中返回匹配的响应，不访问网络。
+// 相同请求按录制顺序依次返回，用完后重复最后一个
+type CassetteReplayer struct {
+	mu      sync.Mutex
+	entries map[string][]CassetteResponse
+	used    map[string]int
+}
+
+func NewCassetteReplayer(path string) (*CassetteReplayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	r := &CassetteReplayer{
+		entries: map[string][]CassetteResponse{},
+		used:    map[string]int{},
+	}
+	for _, it := range c.Interactions {
+		u, err := neturl.Parse(it.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parse cassette url %s: %w", it.Request.URL, err)
+		}
+		key := interactionKey(it.Request.Method, u, it.Request.Body)
+		r.entries[key] = append(r.entries[key], it.Response)
+	}
+	return r, nil
+}
+
+func (r *CassetteReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, _, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	key := interactionKey(req.Method, req.URL, string(reqBody))
+
+	r.mu.Lock()
+	list := r.entries[key]
+	if len(list) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("replay: no recorded response for %s %s", req.Method, redactURL(req.URL))
+	}
+	idx := min(r.used[key], len(list)-1)
+	r.used[key]++
+	r.mu.Unlock()
+
+	rec := list[idx]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
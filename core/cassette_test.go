@@ -0,0 +1,105 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordReplay(t *testing.T) {
+	up, requests := recordingUpstream(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := NewCassetteRecorder(path, nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := []struct{ method, url, body string }{
+		{http.MethodGet, "/pets?api_key=secret", ""},
+		{http.MethodPost, "/pets", `{"name":"Rex","owner":{"password":"hunter2"}}`},
+		{http.MethodGet, "/pets/1", ""},
+	}
+	do := func(rt http.RoundTripper, method, url, body string) string {
+		t.Helper()
+		req, err := http.NewRequest(method, up.URL+url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+	var recorded []string
+	for _, c := range calls {
+		recorded = append(recorded, do(rec, c.method, c.url, c.body))
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		t.Fatalf("cassette is not valid JSON: %v\n%s", err, data)
+	}
+	if len(cassette.Interactions) != len(calls) {
+		t.Fatalf("recorded %d interactions, want %d", len(cassette.Interactions), len(calls))
+	}
+	for _, secret := range []string{"secret", "hunter2", "issued-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains unredacted credential %s", secret)
+		}
+	}
+
+	rep, err := NewCassetteReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range calls {
+		want := string(redactBody([]byte(recorded[i])))
+		if got := do(rep, c.method, c.url, c.body); got != want {
+			t.Errorf("replay %s %s = %s, want %s", c.method, c.url, got, want)
+		}
+	}
+	// 凭据不同的请求同样能匹配到录制结果
+	if got := do(rep, http.MethodPost, "/pets", `{"name":"Rex","owner":{"password":"other"}}`); !strings.Contains(got, `"id":1234567`) {
+		t.Errorf("replay with different password = %s", got)
+	}
+	if n := len(requests()); n != len(calls) {
+		t.Errorf("upstream received %d requests, want %d", n, len(calls))
+	}
+}
+
+func TestNewCassetteRecorderExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := os.WriteFile(path, []byte(`{"interactions":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		overwrite bool
+		wantErr   bool
+	}{
+		{name: "refuses existing file", wantErr: true},
+		{name: "overwrite allowed", overwrite: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCassetteRecorder(path, nil, tt.overwrite, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
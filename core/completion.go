@@ -0,0 +1,295 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// extCompletion 指定参数的候选值：字符串列表，或 {operation, field} 表示调用列表操作获取
+const extCompletion = "x-mcp-completion"
+
+const (
+	completionMaxValues = 100 // MCP 单次最多返回的候选数
+	completionCacheTTL  = 5 * time.Minute
+)
+
+// completionSource 描述一个参数的候选值来源
+type completionSource struct {
+	values    []string
+	operation string // 列表操作的工具名
+	field     string // 响应中取值的字段路径，以 . 分隔
+}
+
+type completionCacheEntry struct {
+	values  []string
+	expires time.Time
+}
+
+// Completer 实现 MCP completion，为资源模板参数 (即对应工具的参数) 与提示参数提供候选值。
+// 列表操作的结果按会话缓存
+type Completer struct {
+	mu        sync.Mutex
+	params    map[string]map[string]completionSource // 工具名 -> 参数名
+	callers   map[string]*opCaller
+	templates map[string]string            // URI 模板 -> 工具名
+	prompts   map[string]map[string]string // 提示名 -> 参数名 -> "tool.param"
+	values    map[string]map[string][]string
+	cache     map[string]map[string]completionCacheEntry // 会话 -> 工具名.字段
+}
+
+func NewCompleter() *Completer {
+	return &Completer{
+		params:    map[string]map[string]completionSource{},
+		callers:   map[string]*opCaller{},
+		templates: map[string]string{},
+		prompts:   map[string]map[string]string{},
+		values:    map[string]map[string][]string{},
+		cache:     map[string]map[string]completionCacheEntry{},
+	}
+}
+
+// paramCompletion 依次使用 x-mcp-completion、enum 与布尔类型推导候选值
+func paramCompletion(p *v3high.Parameter) (completionSource, bool) {
+	if v, ok := extValue(p.Extensions, extCompletion); ok {
+		switch vv := v.(type) {
+		case []any:
+			src := completionSource{}
+			for _, x := range vv {
+				src.values = append(src.values, formatValue(x))
+			}
+			return src, true
+		case map[string]any:
+			op, _ := vv["operation"].(string)
+			field, _ := vv["field"].(string)
+			if op != "" {
+				return completionSource{operation: op, field: coalesce(field, "id")}, true
+			}
+		}
+	}
+	if p.Schema == nil {
+		return completionSource{}, false
+	}
+	s := p.Schema.Schema()
+	if s == nil {
+		return completionSource{}, false
+	}
+	if len(s.Enum) > 0 {
+		return completionSource{values: yamlNodesToStrs(s.Enum)}, true
+	}
+	if firstType(s.Type) == "boolean" {
+		return completionSource{values: []string{"true", "false"}}, true
+	}
+	return completionSource{}, false
+}
+
+func (c *Completer) addOperation(name string, params []*v3high.Parameter, caller *opCaller) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callers[name] = caller
+	for _, p := range params {
+		if p == nil || paramHidden(p) {
+			continue
+		}
+		if src, ok := paramCompletion(p); ok {
+			if c.params[name] == nil {
+				c.params[name] = map[string]completionSource{}
+			}
+			c.params[name][p.Name] = src
+		}
+	}
+}
+
+func (c *Completer) addTemplate(uriTemplate, tool string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.templates[uriTemplate] = tool
+}
+
+// addPrompt 记录提示参数的候选值，或绑定到 "tool.param" 形式的工具参数
+func (c *Completer) addPrompt(prompt string, args []PromptArgument) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, a := range args {
+		if len(a.Values) > 0 {
+			if c.values[prompt] == nil {
+				c.values[prompt] = map[string][]string{}
+			}
+			c.values[prompt][a.Name] = a.Values
+		}
+		if a.Parameter != "" {
+			if c.prompts[prompt] == nil {
+				c.prompts[prompt] = map[string]string{}
+			}
+			c.prompts[prompt][a.Name] = a.Parameter
+		}
+	}
+}
+
+// ForgetSession 清除会话的列表缓存
+func (c *Completer) ForgetSession(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cache, id)
+}
+
+func (c *Completer) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, context mcp.CompleteContext) (*mcp.Completion, error) {
+	c.mu.Lock()
+	values, hasValues := c.values[promptName][argument.Name]
+	ref := c.prompts[promptName][argument.Name]
+	c.mu.Unlock()
+
+	if hasValues {
+		return filterCompletion(values, argument.Value), nil
+	}
+	tool, param, ok := strings.Cut(ref, ".")
+	if !ok {
+		return filterCompletion(nil, argument.Value), nil
+	}
+	return c.completeParam(ctx, tool, param, argument.Value)
+}
+
+func (c *Completer) CompleteResourceArgument(ctx context.Context, uri string, argument mcp.CompleteArgument, context mcp.CompleteContext) (*mcp.Completion, error) {
+	c.mu.Lock()
+	tool := c.templates[uri]
+	c.mu.Unlock()
+	return c.completeParam(ctx, tool, argument.Name, argument.Value)
+}
+
+func (c *Completer) completeParam(ctx context.Context, tool, param, prefix string) (*mcp.Completion, error) {
+	c.mu.Lock()
+	src, ok := c.params[tool][param]
+	c.mu.Unlock()
+	if !ok {
+		return filterCompletion(nil, prefix), nil
+	}
+	if src.operation == "" {
+		return filterCompletion(src.values, prefix), nil
+	}
+	values, err := c.lookup(ctx, src.operation, src.field)
+	if err != nil {
+		return nil, err
+	}
+	return filterCompletion(values, prefix), nil
+}
+
+// lookup 调用列表操作并从响应中取出字段值，结果按会话缓存
+func (c *Completer) lookup(ctx context.Context, tool, field string) ([]string, error) {
+	sessionID := ""
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		sessionID = cs.SessionID()
+	}
+	key := tool + "." + field
+
+	c.mu.Lock()
+	caller := c.callers[tool]
+	if e, ok := c.cache[sessionID][key]; ok && time.Now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.values, nil
+	}
+	c.mu.Unlock()
+	if caller == nil {
+		return nil, fmt.Errorf("completion: unknown operation %q", tool)
+	}
+
+	body, err := caller.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("completion: %s: %w", tool, err)
+	}
+	if body == nil {
+		return nil, nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("completion: %s: %w", tool, err)
+	}
+	values := collectField(v, strings.Split(field, "."))
+
+	c.mu.Lock()
+	if c.cache[sessionID] == nil {
+		c.cache[sessionID] = map[string]completionCacheEntry{}
+	}
+	c.cache[sessionID][key] = completionCacheEntry{values: values, expires: time.Now().Add(completionCacheTTL)}
+	c.mu.Unlock()
+	return values, nil
+}
+
+// fetch 以参数默认值调用操作并返回响应体，mock 模式下返回示例响应，
+// 请求被 dry-run 或确认拦截时返回 nil
+func (c *opCaller) fetch(ctx context.Context) ([]byte, error) {
+	if c.o.mock {
+		_, body, err := mockBody(c.o.operation, "")
+		return []byte(body), err
+	}
+	raw := applyParamValues(nil, c.o.paramDefaults, c.o.paramFixed)
+	if missing := missingArgs(raw, c.o.requiredArgs); len(missing) > 0 {
+		return nil, fmt.Errorf("list operation has required argument %s", missing[0].name)
+	}
+	p, err := c.prepare(raw)
+	if err != nil {
+		return nil, err
+	}
+	resp, res, err := c.call(ctx, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		// dry-run 或未获确认时不查询上游
+		return nil, nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	return body, nil
+}
+
+// collectField 按路径取值，途经的数组逐个展开
+func collectField(v any, path []string) []string {
+	switch vv := v.(type) {
+	case []any:
+		var out []string
+		for _, x := range vv {
+			out = append(out, collectField(x, path)...)
+		}
+		return out
+	case map[string]any:
+		if len(path) == 0 || path[0] == "" {
+			return nil
+		}
+		return collectField(vv[path[0]], path[1:])
+	case nil:
+		return nil
+	}
+	if len(path) > 0 && path[0] != "" {
+		return nil
+	}
+	return []string{formatValue(v)}
+}
+
+func filterCompletion(values []string, prefix string) *mcp.Completion {
+	out := []string{}
+	for _, v := range values {
+		if strings.HasPrefix(strings.ToLower(v), strings.ToLower(prefix)) && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	total := len(out)
+	if total > completionMaxValues {
+		return &mcp.Completion{Values: out[:completionMaxValues], Total: total, HasMore: true}
+	}
+	return &mcp.Completion{Values: out, Total: total}
+}
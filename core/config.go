@@ -0,0 +1,380 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 是服务的完整配置，来自 YAML / JSON / TOML 配置文件，环境变量可覆盖其中的值
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	OpenAPI  OpenAPIConfig  `yaml:"openapi"`
+	Upstream UpstreamConfig `yaml:"upstream"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Tools    ToolsConfig    `yaml:"tools"`
+	Limits   LimitsConfig   `yaml:"limits"`
+
+	Prompts        []PromptTemplate `yaml:"prompts"`
+	PromptsFile    string           `yaml:"promptsFile"`
+	Workflows      []Workflow       `yaml:"workflows"`
+	WorkflowsFile  string           `yaml:"workflowsFile"`
+	Transforms     []Transform      `yaml:"transforms"`
+	TransformsFile string           `yaml:"transformsFile"`
+}
+
+type ServerConfig struct {
+	Transport   string `yaml:"transport"` // stdio / sse / stream
+	Addr        string `yaml:"addr"`      // sse 与 stream 的监听地址
+	LogOutput   bool   `yaml:"logOutput"`
+	MetricsAddr string `yaml:"metricsAddr"`
+}
+
+type OpenAPIConfig struct {
+	Src               string            `yaml:"src"`
+	Overlays          []string          `yaml:"overlays"`
+	BaseURL           string            `yaml:"baseURL"`
+	Server            string            `yaml:"server"`
+	ServerVariables   map[string]string `yaml:"serverVariables"`
+	Resources         bool              `yaml:"resources"`
+	ResourceTemplates bool              `yaml:"resourceTemplates"`
+	Prompts           bool              `yaml:"prompts"` // 按标签生成提示
+	Links             bool              `yaml:"links"`
+}
+
+type UpstreamConfig struct {
+	TransportConfig    `yaml:",inline"`
+	UpstreamPoolConfig `yaml:",inline"`
+
+	Servers []string          `yaml:"servers"` // 上游池地址，["openapi"] 表示文档中的全部 servers
+	Headers map[string]string `yaml:"headers"`
+	Cookies bool              `yaml:"cookies"`
+	Record  string            `yaml:"record"`
+	Replay  string            `yaml:"replay"`
+
+	RecordOverwrite bool `yaml:"recordOverwrite"` // 允许覆盖已存在的 cassette 文件
+}
+
+type AuthConfig struct {
+	Authorization string `yaml:"authorization"` // 需要 HTTP Basic 认证的操作使用的 Authorization 头
+}
+
+type ToolsConfig struct {
+	Mode               string        `yaml:"mode"`
+	Filter             ToolFilter    `yaml:"filter"`
+	DestructiveMethods []string      `yaml:"destructiveMethods"`
+	DryRun             bool          `yaml:"dryRun"`
+	Mock               bool          `yaml:"mock"`
+	Confirm            ConfirmPolicy `yaml:"confirm"`
+	Batch              BatchConfig   `yaml:"batch"`
+}
+
+type BatchConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	Concurrency int  `yaml:"concurrency"`
+}
+
+type LimitsConfig struct {
+	RatePerSecond float64 `yaml:"ratePerSecond"` // 每个会话每秒允许的工具调用数，0 表示不限制
+}
+
+// DefaultConfig 返回未配置任何项时的默认值
+func DefaultConfig() *Config {
+	return &Config{
+		Server:   ServerConfig{Transport: "stdio", Addr: ":8080"},
+		OpenAPI:  OpenAPIConfig{Resources: true, Prompts: true, Links: true},
+		Upstream: UpstreamConfig{Cookies: true},
+		Tools: ToolsConfig{
+			Mode:    ToolModeOperations,
+			Confirm: ConfirmPolicy{Fallback: ConfirmFallbackReject},
+			Batch:   BatchConfig{Concurrency: 4},
+		},
+	}
+}
+
+// LoadConfig 读取配置文件 (path 为空时只使用默认值)，应用环境变量覆盖后校验。
+// 文件中取值里的 ${VAR} 与 ${VAR:-default} 替换为环境变量的值
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeConfig(b, filepath.Ext(path), cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.normalize()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+var envRefRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?}`)
+
+// interpolateEnv 替换标量值中的环境变量引用，注释不受影响
+func interpolateEnv(n *yaml.Node) error {
+	var errs []error
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && strings.Contains(n.Value, "${") {
+			out := envRefRe.ReplaceAllStringFunc(n.Value, func(m string) string {
+				sub := envRefRe.FindStringSubmatch(m)
+				if v, ok := os.LookupEnv(sub[1]); ok {
+					return v
+				}
+				if !strings.Contains(m, ":-") {
+					errs = append(errs, fmt.Errorf("line %d: environment variable %s is not set", n.Line, sub[1]))
+				}
+				return sub[2]
+			})
+			if out != n.Value {
+				// 重新推断类型，使 ${PORT} 这样的值可以解析为数字
+				n.Value, n.Tag = out, ""
+			}
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(n)
+	return errors.Join(errs...)
+}
+
+// decodeConfig 按扩展名解析，未知字段视为错误。TOML 先转换为 JSON，再按同一套字段规则解析
+func decodeConfig(b []byte, ext string, cfg *Config) error {
+	if strings.EqualFold(ext, ".toml") {
+		var m map[string]any
+		if _, err := toml.Decode(string(b), &m); err != nil {
+			return err
+		}
+		var err error
+		if b, err = json.Marshal(m); err != nil {
+			return err
+		}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		return nil
+	}
+	if err := interpolateEnv(&root); err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(&root)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(out))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// envSetter 把环境变量解析到配置字段，未设置或为空的变量不覆盖
+type envSetter struct {
+	errs []error
+}
+
+func (e *envSetter) lookup(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+func (e *envSetter) str(key string, dst *string) {
+	if v, ok := e.lookup(key); ok {
+		*dst = v
+	}
+}
+
+func (e *envSetter) list(key string, dst *[]string) {
+	if _, ok := e.lookup(key); ok {
+		*dst = LoadEnvList(key)
+	}
+}
+
+func (e *envSetter) bool(key string, dst *bool) {
+	if v, ok := e.lookup(key); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a boolean", key, v))
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e *envSetter) int(key string, dst *int) {
+	if v, ok := e.lookup(key); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not an integer", key, v))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envSetter) float(key string, dst *float64) {
+	if v, ok := e.lookup(key); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a number", key, v))
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envSetter) duration(key string, dst *time.Duration) {
+	if v, ok := e.lookup(key); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %w", key, err))
+			return
+		}
+		*dst = d
+	}
+}
+
+func (e *envSetter) json(key string, dst any) {
+	if v, ok := e.lookup(key); ok {
+		if err := json.Unmarshal([]byte(v), dst); err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+}
+
+// applyEnv 使用与配置项对应的环境变量覆盖配置
+func (c *Config) applyEnv() error {
+	e := &envSetter{}
+
+	e.str("MCP_TRANSPORT", &c.Server.Transport)
+	e.str("MCP_BASE_URL", &c.Server.Addr)
+	e.bool("LOG_OUTPUT", &c.Server.LogOutput)
+	e.str("METRICS_ADDR", &c.Server.MetricsAddr)
+
+	e.str("OPENAPI_SRC", &c.OpenAPI.Src)
+	e.list("OPENAPI_OVERLAYS", &c.OpenAPI.Overlays)
+	e.str("OPENAPI_BASE_URL", &c.OpenAPI.BaseURL)
+	e.str("OPENAPI_SERVER", &c.OpenAPI.Server)
+	e.json("OPENAPI_SERVER_VARIABLES", &c.OpenAPI.ServerVariables)
+	e.bool("OPENAPI_RESOURCES", &c.OpenAPI.Resources)
+	e.bool("OPENAPI_RESOURCE_TEMPLATES", &c.OpenAPI.ResourceTemplates)
+	e.bool("OPENAPI_PROMPTS", &c.OpenAPI.Prompts)
+	e.bool("OPENAPI_LINKS", &c.OpenAPI.Links)
+
+	e.str("UPSTREAM_PROXY", &c.Upstream.ProxyURL)
+	e.str("UPSTREAM_CA_FILE", &c.Upstream.CAFile)
+	e.str("UPSTREAM_CLIENT_CERT", &c.Upstream.CertFile)
+	e.str("UPSTREAM_CLIENT_KEY", &c.Upstream.KeyFile)
+	e.str("UPSTREAM_TLS_MIN_VERSION", &c.Upstream.MinTLSVersion)
+	e.bool("UPSTREAM_INSECURE_SKIP_VERIFY", &c.Upstream.InsecureSkipVerify)
+	e.list("UPSTREAM_SERVERS", &c.Upstream.Servers)
+	e.str("UPSTREAM_STRATEGY", &c.Upstream.Strategy)
+	e.str("UPSTREAM_HEALTH_PATH", &c.Upstream.HealthPath)
+	e.duration("UPSTREAM_HEALTH_INTERVAL", &c.Upstream.HealthInterval)
+	e.json("EXTRA_HEADERS", &c.Upstream.Headers)
+	e.bool("USE_COOKIE", &c.Upstream.Cookies)
+	e.str("UPSTREAM_RECORD", &c.Upstream.Record)
+	e.bool("UPSTREAM_RECORD_OVERWRITE", &c.Upstream.RecordOverwrite)
+	e.str("UPSTREAM_REPLAY", &c.Upstream.Replay)
+
+	e.str("AUTHORIZATION_HEADERS", &c.Auth.Authorization)
+
+	e.str("TOOL_MODE", &c.Tools.Mode)
+	e.list("TOOL_INCLUDE_TAGS", &c.Tools.Filter.IncludeTags)
+	e.list("TOOL_EXCLUDE_TAGS", &c.Tools.Filter.ExcludeTags)
+	e.list("TOOL_INCLUDE_PATHS", &c.Tools.Filter.IncludePaths)
+	e.list("TOOL_EXCLUDE_PATHS", &c.Tools.Filter.ExcludePaths)
+	e.list("TOOL_INCLUDE_OPERATIONS", &c.Tools.Filter.IncludeOperations)
+	e.list("TOOL_EXCLUDE_OPERATIONS", &c.Tools.Filter.ExcludeOperations)
+	e.list("TOOL_METHODS", &c.Tools.Filter.Methods)
+	e.bool("TOOL_EXCLUDE_DEPRECATED", &c.Tools.Filter.ExcludeDeprecated)
+	e.list("TOOL_DESTRUCTIVE_METHODS", &c.Tools.DestructiveMethods)
+	e.bool("DRY_RUN", &c.Tools.DryRun)
+	e.bool("MOCK_UPSTREAM", &c.Tools.Mock)
+	e.bool("CONFIRM_DESTRUCTIVE", &c.Tools.Confirm.Enabled)
+	e.list("CONFIRM_TAGS", &c.Tools.Confirm.Tags)
+	e.str("CONFIRM_FALLBACK", &c.Tools.Confirm.Fallback)
+	e.bool("BATCH_TOOL", &c.Tools.Batch.Enabled)
+	e.int("BATCH_CONCURRENCY", &c.Tools.Batch.Concurrency)
+
+	e.float("RATE_LIMIT_PER_SECOND", &c.Limits.RatePerSecond)
+
+	e.str("PROMPTS_FILE", &c.PromptsFile)
+	e.str("WORKFLOWS_FILE", &c.WorkflowsFile)
+	e.str("TRANSFORMS_FILE", &c.TransformsFile)
+
+	return errors.Join(e.errs...)
+}
+
+// normalize 将枚举取值转换为小写，运行时按小写精确比较
+func (c *Config) normalize() {
+	for _, v := range []*string{&c.Server.Transport, &c.Tools.Mode, &c.Tools.Confirm.Fallback, &c.Upstream.Strategy} {
+		*v = strings.ToLower(strings.TrimSpace(*v))
+	}
+}
+
+// Validate 检查取值范围与互斥项，一次返回全部错误。枚举取值区分大小写，LoadConfig 已将其转换为小写
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, field, format string, a ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, a...)))
+		}
+	}
+	oneOf := func(field, v string, allowed ...string) {
+		if slices.Contains(allowed, v) {
+			return
+		}
+		check(false, field, "%q must be one of %s", v, strings.Join(allowed, ", "))
+	}
+
+	oneOf("server.transport", c.Server.Transport, "stdio", "sse", "stream")
+	oneOf("tools.mode", c.Tools.Mode, ToolModeOperations, ToolModeTags, ToolModeMeta)
+	oneOf("tools.confirm.fallback", c.Tools.Confirm.Fallback, ConfirmFallbackReject, ConfirmFallbackAllow)
+	if c.Upstream.Strategy != "" {
+		oneOf("upstream.strategy", c.Upstream.Strategy, StrategyPriority, StrategyRoundRobin)
+	}
+	if c.Upstream.MinTLSVersion != "" {
+		_, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(c.Upstream.MinTLSVersion), "tls")]
+		check(ok, "upstream.minTLSVersion", "%q must be one of 1.0, 1.1, 1.2, 1.3", c.Upstream.MinTLSVersion)
+	}
+	check((c.Upstream.CertFile == "") == (c.Upstream.KeyFile == ""), "upstream.certFile",
+		"certFile and keyFile must be set together")
+	check(c.Upstream.Record == "" || c.Upstream.Replay == "", "upstream.record",
+		"record and replay are mutually exclusive")
+	check(c.Upstream.HealthInterval >= 0, "upstream.healthInterval", "must not be negative")
+	check(!c.Tools.Batch.Enabled || c.Tools.Batch.Concurrency > 0, "tools.batch.concurrency",
+		"must be a positive integer, got %d", c.Tools.Batch.Concurrency)
+	check(c.Limits.RatePerSecond >= 0, "limits.ratePerSecond", "must not be negative")
+	for _, m := range c.Tools.Filter.Methods {
+		oneOf("tools.filter.methods", strings.ToUpper(m), "GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
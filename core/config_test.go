@@ -0,0 +1,189 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // 文件名，为空时不使用配置文件
+		content string
+		env     map[string]string
+		check   func(t *testing.T, c *Config)
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, c *Config) {
+				if c.Server.Transport != "stdio" || c.Tools.Mode != ToolModeOperations || !c.Upstream.Cookies {
+					t.Errorf("unexpected defaults %+v", c)
+				}
+			},
+		},
+		{
+			name: "interpolation with defaults",
+			file: "config.yaml",
+			content: `
+openapi:
+  src: ${SPEC_DIR}/openapi.yaml
+  baseURL: ${BASE_URL:-https://fallback.example.com}
+limits:
+  ratePerSecond: ${RATE}
+`,
+			env: map[string]string{"SPEC_DIR": "/specs", "RATE": "2.5"},
+			check: func(t *testing.T, c *Config) {
+				if c.OpenAPI.Src != "/specs/openapi.yaml" {
+					t.Errorf("src = %q", c.OpenAPI.Src)
+				}
+				if c.OpenAPI.BaseURL != "https://fallback.example.com" {
+					t.Errorf("baseURL = %q", c.OpenAPI.BaseURL)
+				}
+				if c.Limits.RatePerSecond != 2.5 {
+					t.Errorf("ratePerSecond = %v", c.Limits.RatePerSecond)
+				}
+			},
+		},
+		{
+			name:    "unset variable without default",
+			file:    "config.yaml",
+			content: "openapi:\n  src: ${MISSING_SPEC_VAR}\n",
+			wantErr: "MISSING_SPEC_VAR is not set",
+		},
+		{
+			name: "environment overrides file",
+			file: "config.yaml",
+			content: `
+openapi:
+  src: ./file.yaml
+upstream:
+  healthInterval: 10s
+tools:
+  dryRun: false
+  filter:
+    includeTags: [pets]
+`,
+			env: map[string]string{
+				"OPENAPI_SRC":              "./env.yaml",
+				"UPSTREAM_HEALTH_INTERVAL": "1m",
+				"DRY_RUN":                  "true",
+				"TOOL_INCLUDE_TAGS":        "store, users",
+			},
+			check: func(t *testing.T, c *Config) {
+				if c.OpenAPI.Src != "./env.yaml" {
+					t.Errorf("src = %q", c.OpenAPI.Src)
+				}
+				if c.Upstream.HealthInterval != time.Minute {
+					t.Errorf("healthInterval = %v", c.Upstream.HealthInterval)
+				}
+				if !c.Tools.DryRun {
+					t.Error("dryRun not overridden")
+				}
+				if got := strings.Join(c.Tools.Filter.IncludeTags, ","); got != "store,users" {
+					t.Errorf("includeTags = %q", got)
+				}
+			},
+		},
+		{
+			name:    "empty environment variable keeps file value",
+			file:    "config.yaml",
+			content: "openapi:\n  src: ./file.yaml\n",
+			env:     map[string]string{"OPENAPI_SRC": " "},
+			check: func(t *testing.T, c *Config) {
+				if c.OpenAPI.Src != "./file.yaml" {
+					t.Errorf("src = %q", c.OpenAPI.Src)
+				}
+			},
+		},
+		{
+			name:    "enum values are lower-cased",
+			file:    "config.yaml",
+			content: "server:\n  transport: SSE\nupstream:\n  strategy: Round-Robin\n",
+			env:     map[string]string{"TOOL_MODE": "Tags", "CONFIRM_FALLBACK": "ALLOW"},
+			check: func(t *testing.T, c *Config) {
+				if c.Server.Transport != "sse" || c.Upstream.Strategy != StrategyRoundRobin ||
+					c.Tools.Mode != ToolModeTags || c.Tools.Confirm.Fallback != ConfirmFallbackAllow {
+					t.Errorf("not normalized: transport=%q strategy=%q mode=%q fallback=%q",
+						c.Server.Transport, c.Upstream.Strategy, c.Tools.Mode, c.Tools.Confirm.Fallback)
+				}
+			},
+		},
+		{
+			name:    "toml",
+			file:    "config.toml",
+			content: "[openapi]\nsrc = \"./spec.yaml\"\n\n[tools.batch]\nenabled = true\nconcurrency = 8\n",
+			check: func(t *testing.T, c *Config) {
+				if c.OpenAPI.Src != "./spec.yaml" || !c.Tools.Batch.Enabled || c.Tools.Batch.Concurrency != 8 {
+					t.Errorf("unexpected config %+v %+v", c.OpenAPI, c.Tools.Batch)
+				}
+			},
+		},
+		{
+			name:    "unknown field",
+			file:    "config.yaml",
+			content: "tools:\n  moed: meta\n",
+			wantErr: "field moed not found",
+		},
+		{
+			name:    "invalid environment value",
+			env:     map[string]string{"BATCH_CONCURRENCY": "many"},
+			wantErr: "BATCH_CONCURRENCY",
+		},
+		{
+			name:    "record and replay",
+			env:     map[string]string{"UPSTREAM_RECORD": "a.json", "UPSTREAM_REPLAY": "b.json"},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:    "invalid enum",
+			env:     map[string]string{"TOOL_MODE": "everything", "TOOL_METHODS": "get,fetch"},
+			wantErr: `"FETCH" must be one of`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeConfig(t, tt.file, tt.content)
+			}
+
+			c, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestConfigValidateCase(t *testing.T) {
+	// Validate 不做转换，未经 LoadConfig 的大小写不一致取值会被拒绝
+	c := DefaultConfig()
+	c.Tools.Mode = "Meta"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for mixed-case tools.mode")
+	}
+}
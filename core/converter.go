@@ -0,0 +1,128 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pb33f/libopenapi"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// ToolRegistry 是可以注册工具的 MCP 服务，*server.MCPServer 满足该接口
+type ToolRegistry interface {
+	AddTool(tool mcp.Tool, handler server.ToolHandlerFunc)
+}
+
+// ResourceTemplateRegistry 是可以注册资源模板的 MCP 服务，*server.MCPServer 满足该接口
+type ResourceTemplateRegistry interface {
+	AddResourceTemplate(template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc)
+}
+
+type toolList []server.ServerTool
+
+func (l *toolList) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	*l = append(*l, server.ServerTool{Tool: tool, Handler: handler})
+}
+
+type templateList []server.ServerResourceTemplate
+
+func (l *templateList) AddResourceTemplate(template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc) {
+	*l = append(*l, server.ServerResourceTemplate{Template: template, Handler: handler})
+}
+
+// Converter 将 OpenAPI 文档转换为 MCP 工具，供嵌入其它 Go 服务使用。
+// 它只通过 Option 配置，不读取环境变量，也不使用全局的 session.Instance()；
+// 上游请求使用 WithHTTPClient / WithTransport，凭据与 hooks 分别由 WithCredentials、WithHooks 注入
+type Converter struct {
+	model *libopenapi.DocumentModel[v3high.Document]
+	opts  []Option
+}
+
+func NewConverter(v3Model *libopenapi.DocumentModel[v3high.Document], opts ...Option) *Converter {
+	return &Converter{model: v3Model, opts: opts}
+}
+
+// Tools 返回生成的工具：按 ToolMode 生成的操作工具、元工具或分组工具，以及 workflow 与批量工具
+func (c *Converter) Tools() ([]server.ServerTool, error) {
+	tools, _, err := c.Convert()
+	return tools, err
+}
+
+// Convert 返回生成的工具与资源模板，资源模板只在开启 WithResourceTemplates 时生成。
+// 工具名重复 (例如两个标签清理后同名，或标签与 workflow、batch_operation 同名) 时返回错误
+func (c *Converter) Convert() ([]server.ServerTool, []server.ServerResourceTemplate, error) {
+	var tools toolList
+	var templates templateList
+	if err := c.convert(&tools, &templates); err != nil {
+		return nil, nil, err
+	}
+	seen := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		if seen[t.Tool.Name] {
+			return nil, nil, fmt.Errorf("duplicate tool name %s: operations, tag groups, workflows and %s must have distinct names",
+				t.Tool.Name, BatchToolName)
+		}
+		seen[t.Tool.Name] = true
+	}
+	return tools, templates, nil
+}
+
+// Register 将工具注册到 r，r 同时实现 ResourceTemplateRegistry 时一并注册资源模板。
+// 转换失败时不注册任何内容
+func (c *Converter) Register(r ToolRegistry) error {
+	tools, templates, err := c.Convert()
+	if err != nil {
+		return err
+	}
+	for _, t := range tools {
+		r.AddTool(t.Tool, t.Handler)
+	}
+	if tr, ok := r.(ResourceTemplateRegistry); ok {
+		for _, t := range templates {
+			tr.AddResourceTemplate(t.Template, t.Handler)
+		}
+	}
+	return nil
+}
+
+func (c *Converter) convert(toolReg ToolRegistry, templateReg ResourceTemplateRegistry) error {
+	doc := c.model.Model
+	o := newOptions(c.opts)
+
+	tools, err := buildOperationTools(o.baseURL, o.headers, doc, c.opts)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tools {
+		if o.completer != nil {
+			o.completer.addOperation(t.name, t.params, t.caller)
+		}
+		if o.resourceTemplates {
+			addResourceTemplate(templateReg, t.name, t.path, t.method, t.op, t.item,
+				t.caller.baseURL, t.caller.extraHeaders, t.caller.o, t.opts)
+		}
+	}
+
+	if err := addWorkflowTools(toolReg, o.workflows, tools); err != nil {
+		return err
+	}
+	if o.batchConcurrency > 0 {
+		addBatchTool(toolReg, tools, o.batchConcurrency, o.rateLimiter)
+	}
+
+	switch o.toolMode {
+	case "", ToolModeOperations:
+		for _, t := range tools {
+			toolReg.AddTool(t.tool, t.handler)
+		}
+	case ToolModeMeta:
+		addMetaTools(toolReg, tools)
+	case ToolModeTags:
+		return addTagTools(toolReg, doc, tools)
+	default:
+		return fmt.Errorf("unknown tool mode %q", o.toolMode)
+	}
+	return nil
+}
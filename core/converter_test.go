@@ -0,0 +1,56 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+const tagsSpec = `
+openapi: 3.0.3
+info: {title: Pets, version: 1.0.0}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [Pets]
+      responses:
+        '200': {description: ok}
+  /store:
+    get:
+      operationId: getStore
+      tags: [%s]
+      responses:
+        '200': {description: ok}
+`
+
+func TestConvertDuplicateToolNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "distinct tags", tag: "store"},
+		{name: "tags sanitize to the same name", tag: "pets", wantErr: "duplicate tool name pets"},
+		{name: "tag and workflow", tag: "adopt",
+			opts:    []Option{WithWorkflows([]Workflow{{Name: "adopt", Steps: []WorkflowStep{{ID: "list", Operation: "listPets"}}}})},
+			wantErr: "duplicate tool name adopt"},
+		{name: "tag and batch tool", tag: BatchToolName, opts: []Option{WithBatchTool(2)}, wantErr: "duplicate tool name " + BatchToolName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := loadTestDoc(t, strings.Replace(tagsSpec, "%s", tt.tag, 1))
+			opts := append([]Option{WithBaseURL("http://localhost"), WithToolMode(ToolModeTags)}, tt.opts...)
+			_, err := NewConverter(doc, opts...).Tools()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 保留参数，不会作为请求参数发送到上游
+const (
+	ArgDryRun = "_dry_run" // true 时只返回构造好的请求
+)
+
+var reservedArgs = []string{ArgDryRun, ArgMockStatus}
+
+// popReservedArgs 从调用参数中取出保留参数，与操作参数 (paramIn) 同名的参数按普通参数处理
+func popReservedArgs(args map[string]any, paramIn map[string]string) (reserved, rest map[string]any) {
+	reserved = map[string]any{}
+	rest = make(map[string]any, len(args))
+	for k, v := range args {
+		if _, isParam := paramIn[k]; slices.Contains(reservedArgs, k) && !isParam {
+			reserved[k] = v
+		} else {
+			rest[k] = v
+		}
+	}
+	return reserved, rest
+}
+
+// reservedArgOptions 在输入 schema 中声明可用的保留参数，与操作参数同名时不声明
+func reservedArgOptions(paramIn map[string]string, o *options) []mcp.ToolOption {
+	var opts []mcp.ToolOption
+	if _, ok := paramIn[ArgDryRun]; !ok {
+		opts = append(opts, mcp.WithBoolean(ArgDryRun,
+			mcp.Description("Return the request that would be sent (URL, redacted headers, body and curl command) instead of calling the upstream API")))
+	}
+	if _, ok := paramIn[ArgMockStatus]; !ok && o.mock {
+		opts = append(opts, mcp.WithString(ArgMockStatus,
+			mcp.Description("Status code of the mocked response to return, e.g. 404. Defaults to the first success response")))
+	}
+	return opts
+}
+
+func reservedBool(reserved map[string]any, key string) bool {
+	switch v := reserved[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+const redacted = "***"
+
+var secretNameParts = []string{"auth", "token", "secret", "password", "key", "cookie", "session", "signature"}
+
+// isSecretName 判断请求头或查询参数名是否可能携带凭据
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range secretNameParts {
+		if strings.Contains(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		if isSecretName(k) {
+			out[k] = []string{redacted}
+		} else {
+			out[k] = slices.Clone(vs)
+		}
+	}
+	return out
+}
+
+// redactBody 把 JSON 中名称像凭据的字段替换为 ***，非 JSON 或没有此类字段时原样返回
+func redactBody(b []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return b
+	}
+	if !redactValue(v) {
+		return b
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// redactValue 原地脱敏，返回是否有字段被替换
+func redactValue(v any) bool {
+	changed := false
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, x := range vv {
+			if isSecretName(k) {
+				if x != nil {
+					vv[k] = redacted
+					changed = true
+				}
+				continue
+			}
+			changed = redactValue(x) || changed
+		}
+	case []any:
+		for _, x := range vv {
+			changed = redactValue(x) || changed
+		}
+	}
+	return changed
+}
+
+func redactURL(u *neturl.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	for k := range q {
+		if isSecretName(k) {
+			q.Set(k, redacted)
+		}
+	}
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// curlCommand 生成与请求等价的 curl 命令，凭据已脱敏
+func curlCommand(req *http.Request, body []byte) string {
+	var sb strings.Builder
+	sb.WriteString("curl -X ")
+	sb.WriteString(req.Method)
+	sb.WriteByte(' ')
+	sb.WriteString(shellQuote(redactURL(req.URL)))
+
+	h := redactHeaders(req.Header)
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			sb.WriteString(" \\\n  -H ")
+			sb.WriteString(shellQuote(k + ": " + v))
+		}
+	}
+	if len(body) > 0 {
+		sb.WriteString(" \\\n  --data ")
+		sb.WriteString(shellQuote(string(body)))
+	}
+	return sb.String()
+}
+
+// dryRunResult 描述将要发送的请求而不真正发送
+func dryRunResult(req *http.Request, body []byte) *mcp.CallToolResult {
+	headers := map[string]string{}
+	for k, vs := range redactHeaders(req.Header) {
+		headers[k] = strings.Join(vs, ", ")
+	}
+	desc := map[string]any{
+		"dryRun":  true,
+		"method":  req.Method,
+		"url":     redactURL(req.URL),
+		"headers": headers,
+		"curl":    curlCommand(req, body),
+	}
+	if len(body) > 0 {
+		desc["body"] = string(body)
+	}
+	return mcp.NewToolResultStructured(desc, fmt.Sprintf("dry run, request not sent:\n\n%s", desc["curl"]))
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	v3base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// extConfirm 在操作上显式开启或关闭调用前确认
+const extConfirm = "x-mcp-confirm"
+
+const (
+	ConfirmFallbackReject = "reject"
+	ConfirmFallbackAllow  = "allow"
+)
+
+// ConfirmPolicy 决定哪些操作在调用前需要通过 MCP elicitation 获得用户确认
+type ConfirmPolicy struct {
+	Enabled  bool     `yaml:"enabled"`  // 对 destructiveHint 为 true 的操作开启确认
+	Tags     []string `yaml:"tags"`     // 这些标签下的操作同样需要确认
+	Fallback string   `yaml:"fallback"` // 客户端不支持 elicitation 时的处理：reject (默认) / allow
+}
+
+// needConfirm 按扩展、标签、破坏性注解的顺序判断
+func (p ConfirmPolicy) needConfirm(method string, op *v3high.Operation, destructiveMethods []string) bool {
+	if v, ok := extValue(op.Extensions, extConfirm); ok {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	}
+	if !p.Enabled {
+		return false
+	}
+	for _, t := range op.Tags {
+		if containsFold(p.Tags, t) {
+			return true
+		}
+	}
+	ann := toolAnnotation(method, op, destructiveMethods)
+	return ann.DestructiveHint != nil && *ann.DestructiveHint
+}
+
+// elicit 向当前会话的客户端发起 elicitation，客户端未声明该能力时返回
+// server.ErrElicitationNotSupported
+func elicit(ctx context.Context, message string, schema map[string]any) (*mcp.ElicitationResult, error) {
+	cs := server.ClientSessionFromContext(ctx)
+	srv := server.ServerFromContext(ctx)
+	if cs == nil || srv == nil {
+		return nil, server.ErrNoActiveSession
+	}
+	if ci, ok := cs.(server.SessionWithClientInfo); ok && ci.GetClientCapabilities().Elicitation == nil {
+		return nil, server.ErrElicitationNotSupported
+	}
+	return srv.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message:         message,
+			RequestedSchema: schema,
+		},
+	})
+}
+
+// confirmRequest 展示即将发送的请求并等待用户确认，返回 nil 表示可以继续
+func confirmRequest(ctx context.Context, fallback, method, url string, body []byte) *mcp.CallToolResult {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Confirm %s %s", strings.ToUpper(method), url)
+	if len(body) > 0 {
+		sb.WriteString("\n\n")
+		sb.Write(body)
+	}
+
+	res, err := elicit(ctx, sb.String(), map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"confirm": map[string]any{
+				"type":        "boolean",
+				"title":       "Proceed",
+				"description": "Send this request to the upstream API",
+			},
+		},
+		"required": []string{"confirm"},
+	})
+	if err != nil {
+		if fallback == ConfirmFallbackAllow {
+			return nil
+		}
+		return mcp.NewToolResultError("confirmation required but not available: " + err.Error())
+	}
+
+	switch res.Action {
+	case mcp.ElicitationResponseActionDecline:
+		return mcp.NewToolResultError("request declined by user")
+	case mcp.ElicitationResponseActionCancel:
+		return mcp.NewToolResultError("request cancelled by user")
+	}
+	if content, ok := res.Content.(map[string]any); ok {
+		if ok, _ := content["confirm"].(bool); ok {
+			return nil
+		}
+	}
+	return mcp.NewToolResultError("request not confirmed by user")
+}
+
+// requiredArg 是一个必填参数及其 elicitation schema，schema 为 nil 表示无法通过表单收集
+type requiredArg struct {
+	name   string
+	schema map[string]any
+}
+
+// primitiveSchema 生成 elicitation 支持的扁平 schema，复杂类型返回 nil
+func primitiveSchema(s *v3base.Schema, desc string) map[string]any {
+	if s == nil {
+		return map[string]any{"type": "string", "description": desc}
+	}
+	t := firstType(s.Type)
+	switch t {
+	case "", "string", "number", "integer", "boolean":
+	default:
+		return nil
+	}
+	if t == "" {
+		t = "string"
+	}
+	out := map[string]any{"type": t}
+	if d := coalesce(desc, s.Description); d != "" {
+		out["description"] = d
+	}
+	if len(s.Enum) > 0 {
+		out["enum"] = yamlNodesToStrs(s.Enum)
+	}
+	if s.Minimum != nil {
+		out["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		out["maximum"] = *s.Maximum
+	}
+	return out
+}
+
+// collectRequiredArgs 收集没有默认值或固定值的必填参数与请求体，
+// 请求体只有作为 body 参数暴露时才要求提供
+func collectRequiredArgs(params []*v3high.Parameter, op *v3high.Operation, hasBody bool) []requiredArg {
+	var out []requiredArg
+	for _, p := range params {
+		if p == nil || !boolVal(p.Required) {
+			continue
+		}
+		if _, ok := extValue(p.Extensions, extDefault); ok {
+			continue
+		}
+		if _, ok := extValue(p.Extensions, extFixed); ok {
+			continue
+		}
+		var s *v3base.Schema
+		if p.Schema != nil {
+			s = p.Schema.Schema()
+		}
+		out = append(out, requiredArg{name: p.Name, schema: primitiveSchema(s, p.Description)})
+	}
+	if hasBody && boolVal(op.RequestBody.Required) {
+		out = append(out, requiredArg{name: "body"})
+	}
+	return out
+}
+
+func missingArgs(args map[string]any, required []requiredArg) []requiredArg {
+	var out []requiredArg
+	for _, r := range required {
+		if v, ok := args[r.name]; !ok || v == nil || v == "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// elicitMissingArgs 通过 elicitation 向用户收集缺失的必填参数，
+// 无法收集时返回错误结果而不是发送不完整的请求
+func elicitMissingArgs(ctx context.Context, tool string, args map[string]any, missing []requiredArg) (map[string]any, *mcp.CallToolResult) {
+	names := make([]string, 0, len(missing))
+	props := make(map[string]any, len(missing))
+	for _, m := range missing {
+		names = append(names, m.name)
+		props[m.name] = m.schema
+	}
+	errResult := mcp.NewToolResultError(fmt.Sprintf("missing required arguments: %s", strings.Join(names, ", ")))
+
+	for _, m := range missing {
+		if m.schema == nil {
+			return nil, errResult
+		}
+	}
+
+	res, err := elicit(ctx,
+		fmt.Sprintf("%s needs the following values: %s", tool, strings.Join(names, ", ")),
+		map[string]any{"type": "object", "properties": props, "required": names},
+	)
+	if err != nil || res.Action != mcp.ElicitationResponseActionAccept {
+		return nil, errResult
+	}
+	content, _ := res.Content.(map[string]any)
+
+	out := make(map[string]any, len(args)+len(content))
+	for k, v := range args {
+		out[k] = v
+	}
+	for _, name := range names {
+		v, ok := content[name]
+		if !ok || v == nil || v == "" {
+			return nil, errResult
+		}
+		out[name] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// ToolFilter 决定哪些操作会被注册为工具。
+// Include* 之间取并集，为空表示不限制；Exclude* 命中即排除，
+// 但 IncludeOperations 中显式列出的操作不受其它排除规则影响。
+// 路径规则支持 glob (* 不跨越 /，** 跨越 /) 或以 re: 开头的正则
+type ToolFilter struct {
+	IncludeTags       []string `yaml:"includeTags"`
+	ExcludeTags       []string `yaml:"excludeTags"`
+	IncludePaths      []string `yaml:"includePaths"`
+	ExcludePaths      []string `yaml:"excludePaths"`
+	IncludeOperations []string `yaml:"includeOperations"`
+	ExcludeOperations []string `yaml:"excludeOperations"`
+	Methods           []string `yaml:"methods"`
+	ExcludeDeprecated bool     `yaml:"excludeDeprecated"`
+}
+
+type compiledFilter struct {
+	ToolFilter
+	includePaths []*regexp.Regexp
+	excludePaths []*regexp.Regexp
+}
+
+func (f ToolFilter) compile() (*compiledFilter, error) {
+	cf := &compiledFilter{ToolFilter: f}
+	var err error
+	if cf.includePaths, err = compilePathPatterns(f.IncludePaths); err != nil {
+		return nil, err
+	}
+	if cf.excludePaths, err = compilePathPatterns(f.ExcludePaths); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
+
+func compilePathPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		expr := globToRegexp(p)
+		if re, ok := strings.CutPrefix(p, "re:"); ok {
+			expr = re
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteByte('^')
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteByte('$')
+	return sb.String()
+}
+
+func containsFold(list []string, v string) bool {
+	return slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(s, v) })
+}
+
+func matchAny(res []*regexp.Regexp, path string) bool {
+	return slices.ContainsFunc(res, func(re *regexp.Regexp) bool { return re.MatchString(path) })
+}
+
+// match 返回是否保留该操作，以及用于启动报告的原因
+func (f *compiledFilter) match(path, method, name string, op *v3high.Operation) (bool, string) {
+	if !extBool(op.Extensions, extEnabled, true) {
+		return false, "disabled by " + extEnabled
+	}
+	if containsFold(f.ExcludeOperations, name) || containsFold(f.ExcludeOperations, op.OperationId) {
+		return false, "excluded operationId"
+	}
+	if containsFold(f.IncludeOperations, name) || containsFold(f.IncludeOperations, op.OperationId) {
+		return true, "included operationId"
+	}
+	if len(f.Methods) > 0 && !containsFold(f.Methods, method) {
+		return false, "method not allowed"
+	}
+	if f.ExcludeDeprecated && boolVal(op.Deprecated) {
+		return false, "deprecated"
+	}
+	for _, t := range op.Tags {
+		if containsFold(f.ExcludeTags, t) {
+			return false, "excluded tag " + t
+		}
+	}
+	if matchAny(f.excludePaths, path) {
+		return false, "excluded path"
+	}
+
+	if len(f.IncludeOperations) == 0 && len(f.IncludeTags) == 0 && len(f.includePaths) == 0 {
+		return true, ""
+	}
+	for _, t := range op.Tags {
+		if containsFold(f.IncludeTags, t) {
+			return true, "included tag " + t
+		}
+	}
+	if matchAny(f.includePaths, path) {
+		return true, "included path"
+	}
+	return false, "not matched by include rules"
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"testing"
+
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+func TestPathPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/pets", "/pets", true},
+		{"/pets", "/pets/{petId}", false},
+		{"/pets/*", "/pets/{petId}", true},
+		{"/pets/*", "/pets/{petId}/photos", false},
+		{"/pets/**", "/pets/{petId}/photos", true},
+		{"/pets/**", "/petstore", false},
+		{"/**/photos", "/pets/{petId}/photos", true},
+		{"/v?/pets", "/v1/pets", true},
+		{"/v?/pets", "/v10/pets", false},
+		{"/store.json", "/store.json", true},
+		{"/store.json", "/storexjson", false},
+		{"re:^/(pets|store)/", "/store/order", true},
+		{"re:^/(pets|store)/", "/users/1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			res, err := compilePathPatterns([]string{tt.pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchAny(res, tt.path); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compilePathPatterns([]string{"re:("}); err == nil {
+		t.Error("expected error for invalid regular expression")
+	}
+}
+
+func TestToolFilterMatch(t *testing.T) {
+	deprecated := true
+	ops := []struct {
+		path, method string
+		op           *v3high.Operation
+	}{
+		{"/pets", "GET", &v3high.Operation{OperationId: "listPets", Tags: []string{"pets"}}},
+		{"/pets", "POST", &v3high.Operation{OperationId: "createPet", Tags: []string{"pets"}}},
+		{"/pets/{petId}", "DELETE", &v3high.Operation{OperationId: "deletePet", Tags: []string{"pets", "admin"}}},
+		{"/store/order", "POST", &v3high.Operation{OperationId: "placeOrder", Tags: []string{"store"}}},
+		{"/store/inventory", "GET", &v3high.Operation{OperationId: "getInventory", Tags: []string{"store"}, Deprecated: &deprecated}},
+	}
+
+	tests := []struct {
+		name   string
+		filter ToolFilter
+		want   []string
+	}{
+		{
+			name: "no rules",
+			want: []string{"listPets", "createPet", "deletePet", "placeOrder", "getInventory"},
+		},
+		{
+			name:   "include tags",
+			filter: ToolFilter{IncludeTags: []string{"Store"}},
+			want:   []string{"placeOrder", "getInventory"},
+		},
+		{
+			name:   "include tags and paths are a union",
+			filter: ToolFilter{IncludeTags: []string{"store"}, IncludePaths: []string{"/pets"}},
+			want:   []string{"listPets", "createPet", "placeOrder", "getInventory"},
+		},
+		{
+			name:   "exclude tag wins over include path",
+			filter: ToolFilter{IncludePaths: []string{"/pets/**", "/pets"}, ExcludeTags: []string{"admin"}},
+			want:   []string{"listPets", "createPet"},
+		},
+		{
+			name:   "exclude path glob",
+			filter: ToolFilter{ExcludePaths: []string{"/store/*"}},
+			want:   []string{"listPets", "createPet", "deletePet"},
+		},
+		{
+			name:   "methods are case-insensitive",
+			filter: ToolFilter{Methods: []string{"get"}},
+			want:   []string{"listPets", "getInventory"},
+		},
+		{
+			name:   "exclude deprecated",
+			filter: ToolFilter{IncludeTags: []string{"store"}, ExcludeDeprecated: true},
+			want:   []string{"placeOrder"},
+		},
+		{
+			name:   "included operation bypasses other exclusions",
+			filter: ToolFilter{Methods: []string{"GET"}, ExcludeTags: []string{"admin"}, IncludeOperations: []string{"deletePet"}},
+			want:   []string{"deletePet"},
+		},
+		{
+			name:   "excluded operation wins over included operation",
+			filter: ToolFilter{IncludeOperations: []string{"listPets", "createPet"}, ExcludeOperations: []string{"createPet"}},
+			want:   []string{"listPets"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.filter.compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, o := range ops {
+				if ok, _ := f.match(o.path, o.method, o.op.OperationId, o.op); ok {
+					got = append(got, o.op.OperationId)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("included %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("included %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
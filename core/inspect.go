@@ -0,0 +1,101 @@
+package core
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pb33f/libopenapi"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// ToolMapping 描述工具与 HTTP 操作之间的对应关系：参数位置、固定值、确认与 links
+type ToolMapping struct {
+	Name        string              `json:"name"`
+	Method      string              `json:"method"`
+	Path        string              `json:"path"`
+	BaseURL     string              `json:"baseURL,omitempty"` // 启用上游池时为空
+	OperationID string              `json:"operationId,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+	Parameters  []ParamMapping      `json:"parameters,omitempty"`
+	Body        *BodyMapping        `json:"body,omitempty"`
+	Confirm     bool                `json:"confirm"`
+	Links       map[string][]string `json:"links,omitempty"` // 响应码 -> 目标工具
+	Transform   *Transform          `json:"transform,omitempty"`
+	Tool        mcp.Tool            `json:"tool"`
+}
+
+type ParamMapping struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required,omitempty"`
+	Hidden   bool   `json:"hidden,omitempty"` // 不出现在工具参数中
+	Default  any    `json:"default,omitempty"`
+	Fixed    any    `json:"fixed,omitempty"`
+}
+
+type BodyMapping struct {
+	ContentTypes []string `json:"contentTypes"`
+	Required     bool     `json:"required,omitempty"`
+	Exposed      bool     `json:"exposed"` // 只有 application/json 请求体会作为 body 参数
+}
+
+// InspectTools 返回 AddToolFromOpenAPI 会生成的每个操作工具及其映射，不注册任何工具
+func InspectTools(
+	baseURL string,
+	extraHeaders map[string]string,
+	v3Model *libopenapi.DocumentModel[v3high.Document],
+	opts ...Option) ([]ToolMapping, error) {
+
+	tools, err := buildOperationTools(baseURL, extraHeaders, v3Model.Model, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ToolMapping, 0, len(tools))
+	for _, t := range tools {
+		o := t.caller.o
+		m := ToolMapping{
+			Name:        t.name,
+			Method:      t.method,
+			Path:        t.path,
+			OperationID: t.op.OperationId,
+			Tags:        t.op.Tags,
+			Deprecated:  boolVal(t.op.Deprecated),
+			Confirm:     o.needConfirm,
+			Tool:        t.tool,
+		}
+		if o.pool == nil {
+			m.BaseURL = t.caller.baseURL
+		}
+		for _, p := range t.params {
+			if p == nil {
+				continue
+			}
+			m.Parameters = append(m.Parameters, ParamMapping{
+				Name:     p.Name,
+				In:       p.In,
+				Required: boolVal(p.Required),
+				Hidden:   paramHidden(p),
+				Default:  o.paramDefaults[p.Name],
+				Fixed:    o.paramFixed[p.Name],
+			})
+		}
+		if rb := t.op.RequestBody; rb != nil && rb.Content != nil {
+			m.Body = &BodyMapping{Required: boolVal(rb.Required), Exposed: t.caller.hasBody}
+			for el := rb.Content.First(); el != nil; el = el.Next() {
+				m.Body.ContentTypes = append(m.Body.ContentTypes, el.Key())
+			}
+		}
+		if o.transform != nil {
+			m.Transform = &o.transform.src
+		}
+		for code, links := range o.responseLinks {
+			if m.Links == nil {
+				m.Links = map[string][]string{}
+			}
+			for _, l := range links {
+				m.Links[code] = append(m.Links[code], l.target)
+			}
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
@@ -0,0 +1,317 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// responseLink 是响应中声明的 link，目标已解析为工具名
+type responseLink struct {
+	name        string
+	target      string
+	parameters  [][2]string // 参数名与运行时表达式，保持文档顺序
+	requestBody string
+	description string
+}
+
+// NextAction 是根据 link 推荐的后续调用，参数已由本次请求与响应填充
+type NextAction struct {
+	Link        string         `json:"link"`
+	Operation   string         `json:"operation"`
+	Description string         `json:"description,omitempty"`
+	Arguments   map[string]any `json:"arguments"`
+	Missing     []string       `json:"missing,omitempty"` // 无法从响应中取值的参数
+}
+
+// linkTargets 将 operationId 与 "METHOD path" 映射到已包含的工具名
+func linkTargets(doc v3high.Document, filter *compiledFilter) (map[string]string, error) {
+	targets := map[string]string{}
+	err := eachOperation(doc, func(path, method string, item *v3high.PathItem, op *v3high.Operation) error {
+		name := toolName(path, method, op)
+		if ok, _ := filter.match(path, method, name, op); !ok {
+			return nil
+		}
+		if op.OperationId != "" {
+			targets[op.OperationId] = name
+		}
+		targets[method+" "+path] = name
+		return nil
+	})
+	return targets, err
+}
+
+// resolveOperationRef 解析 #/paths/~1users~1{id}/get 形式的本地引用，外部文档不支持
+func resolveOperationRef(ref string) (string, bool) {
+	_, ptr, ok := strings.Cut(ref, "#/paths/")
+	if !ok {
+		return "", false
+	}
+	path, method, ok := strings.Cut(ptr, "/")
+	if !ok {
+		return "", false
+	}
+	if p, err := neturl.PathUnescape(path); err == nil {
+		path = p
+	}
+	path = strings.NewReplacer("~1", "/", "~0", "~").Replace(path)
+	return strings.ToUpper(method) + " " + path, true
+}
+
+// collectLinks 按响应码收集操作的 link，目标不在工具列表中的 link 被忽略
+func collectLinks(op *v3high.Operation, targets map[string]string, o *options) map[string][]responseLink {
+	if op.Responses == nil {
+		return nil
+	}
+	out := map[string][]responseLink{}
+	add := func(code string, r *v3high.Response) {
+		if r == nil || r.Links == nil {
+			return
+		}
+		for el := r.Links.First(); el != nil; el = el.Next() {
+			l := el.Value()
+			if l == nil {
+				continue
+			}
+			key := l.OperationId
+			if key == "" {
+				var ok bool
+				if key, ok = resolveOperationRef(l.OperationRef); !ok {
+					o.logger.Printf("link %s: unsupported operationRef %q", el.Key(), l.OperationRef)
+					continue
+				}
+			}
+			target, ok := targets[key]
+			if !ok {
+				o.logger.Printf("link %s: operation %s is not a tool", el.Key(), key)
+				continue
+			}
+			rl := responseLink{name: el.Key(), target: target, requestBody: l.RequestBody, description: l.Description}
+			if l.Parameters != nil {
+				for p := l.Parameters.First(); p != nil; p = p.Next() {
+					rl.parameters = append(rl.parameters, [2]string{linkParamName(p.Key()), p.Value()})
+				}
+			}
+			out[code] = append(out[code], rl)
+		}
+	}
+	if op.Responses.Codes != nil {
+		for el := op.Responses.Codes.First(); el != nil; el = el.Next() {
+			add(el.Key(), el.Value())
+		}
+	}
+	add("default", op.Responses.Default)
+	return out
+}
+
+// linkParamName 去掉 path.id 形式中用于区分位置的前缀
+func linkParamName(name string) string {
+	if in, rest, ok := strings.Cut(name, "."); ok {
+		switch in {
+		case "path", "query", "header", "cookie":
+			return rest
+		}
+	}
+	return name
+}
+
+// linksDescription 生成附加到工具描述的 link 说明
+func linksDescription(links map[string][]responseLink) string {
+	if len(links) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nLinks (the result suggests these calls):")
+	for _, code := range linkCodes(links) {
+		for _, l := range links[code] {
+			fmt.Fprintf(&sb, "\n- on %s: %s", code, l.target)
+			var args []string
+			for _, p := range l.parameters {
+				args = append(args, p[0]+"="+p[1])
+			}
+			if l.requestBody != "" {
+				args = append(args, "body="+l.requestBody)
+			}
+			if len(args) > 0 {
+				sb.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			if l.description != "" {
+				sb.WriteString(" - " + l.description)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// linkCodes 返回排序后的响应码，default 在最后
+func linkCodes(links map[string][]responseLink) []string {
+	codes := make([]string, 0, len(links))
+	for code := range links {
+		if code != "default" {
+			codes = append(codes, code)
+		}
+	}
+	slices.Sort(codes)
+	if _, ok := links["default"]; ok {
+		codes = append(codes, "default")
+	}
+	return codes
+}
+
+// matchLinks 依次按精确响应码、范围 (2XX) 与 default 选择 link
+func matchLinks(links map[string][]responseLink, status int) []responseLink {
+	code := strconv.Itoa(status)
+	if ls, ok := links[code]; ok {
+		return ls
+	}
+	if ls, ok := links[code[:1]+"XX"]; ok {
+		return ls
+	}
+	if ls, ok := links[code[:1]+"xx"]; ok {
+		return ls
+	}
+	return links["default"]
+}
+
+// linkContext 是运行时表达式可以访问的请求与响应
+type linkContext struct {
+	args        map[string]any
+	url, method string
+	resp        *http.Response
+	respRaw     []byte
+	resBody     any
+}
+
+var linkExprRe = regexp.MustCompile(`\{(\$[^}]+)}`)
+
+// nextActions 对匹配响应码的 link 求值，生成推荐的后续调用
+func nextActions(links map[string][]responseLink, lc *linkContext) []NextAction {
+	matched := matchLinks(links, lc.resp.StatusCode)
+	if len(matched) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(lc.respRaw, &lc.resBody); err != nil {
+		lc.resBody = nil
+	}
+	out := make([]NextAction, 0, len(matched))
+	for _, l := range matched {
+		a := NextAction{Link: l.name, Operation: l.target, Description: l.description, Arguments: map[string]any{}}
+		for _, p := range l.parameters {
+			if v, ok := lc.value(p[1]); ok {
+				a.Arguments[p[0]] = v
+			} else {
+				a.Missing = append(a.Missing, p[0])
+			}
+		}
+		if l.requestBody != "" {
+			if v, ok := lc.value(l.requestBody); ok {
+				a.Arguments["body"] = v
+			} else {
+				a.Missing = append(a.Missing, "body")
+			}
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// withNextActions 把推荐的后续调用附加到结果：文本供模型阅读，_meta.nextActions 供程序读取
+func withNextActions(res *mcp.CallToolResult, actions []NextAction) *mcp.CallToolResult {
+	if len(actions) == 0 {
+		return res
+	}
+	b, err := json.MarshalIndent(actions, "", "  ")
+	if err != nil {
+		return res
+	}
+	res.Content = append(res.Content, mcp.NewTextContent("Suggested next calls:\n"+string(b)))
+	res.Meta = mcp.NewMetaFromMap(map[string]any{"nextActions": actions})
+	return res
+}
+
+// value 计算 link 参数值：$ 开头为运行时表达式，含 {$...} 时按字符串插值，否则为常量
+func (lc *linkContext) value(expr string) (any, bool) {
+	if strings.HasPrefix(expr, "$") {
+		return lc.eval(expr)
+	}
+	ok := true
+	out := linkExprRe.ReplaceAllStringFunc(expr, func(s string) string {
+		v, found := lc.eval(linkExprRe.FindStringSubmatch(s)[1])
+		if !found {
+			ok = false
+			return ""
+		}
+		return formatValue(v)
+	})
+	return out, ok
+}
+
+// eval 计算 OpenAPI 运行时表达式，例如 $response.body#/id、$request.path.id、$response.header.Location
+func (lc *linkContext) eval(expr string) (any, bool) {
+	switch expr {
+	case "$url":
+		return lc.url, true
+	case "$method":
+		return lc.method, true
+	case "$statusCode":
+		return lc.resp.StatusCode, true
+	}
+	source, ref, _ := strings.Cut(expr, "#")
+	switch {
+	case source == "$response.body":
+		return jsonPointer(lc.resBody, ref)
+	case source == "$request.body":
+		return jsonPointer(lc.args["body"], ref)
+	case strings.HasPrefix(source, "$response.header."):
+		v := lc.resp.Header.Get(strings.TrimPrefix(source, "$response.header."))
+		return v, v != ""
+	case strings.HasPrefix(source, "$request."):
+		// 只读取调用参数，避免把 EXTRA_HEADERS 中的凭据带入结果
+		in, name, _ := strings.Cut(strings.TrimPrefix(source, "$request."), ".")
+		for k, v := range lc.args {
+			if k == name || (in == "header" && strings.EqualFold(k, name)) {
+				return v, v != nil
+			}
+		}
+	}
+	return nil, false
+}
+
+// jsonPointer 按 RFC 6901 取值，ptr 为空时返回整个文档
+func jsonPointer(doc any, ptr string) (any, bool) {
+	if ptr == "" {
+		return doc, doc != nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, false
+	}
+	cur := doc
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch v := cur.(type) {
+		case map[string]any:
+			x, ok := v[tok]
+			if !ok {
+				return nil, false
+			}
+			cur = x
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
@@ -0,0 +1,304 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Manifest 是服务注册的工具列表快照，按名称排序，序列化结果稳定，可作为 golden 文件提交
+type Manifest struct {
+	Tools []ManifestTool `json:"tools"`
+}
+
+// ManifestTool 是客户端在 tools/list 中看到的工具定义
+type ManifestTool struct {
+	Name         string             `json:"name"`
+	Title        string             `json:"title,omitempty"`
+	Description  string             `json:"description,omitempty"`
+	InputSchema  map[string]any     `json:"inputSchema"`
+	OutputSchema map[string]any     `json:"outputSchema,omitempty"`
+	Annotations  mcp.ToolAnnotation `json:"annotations"`
+}
+
+// NewManifest 生成服务当前注册的全部工具的快照
+func NewManifest(mcpServer *server.MCPServer) (*Manifest, error) {
+	m := &Manifest{Tools: []ManifestTool{}}
+	for _, st := range mcpServer.ListTools() {
+		// 经过 JSON 往返，RawInputSchema 与 InputSchema 得到相同的表示
+		b, err := json.Marshal(st.Tool)
+		if err != nil {
+			return nil, err
+		}
+		var t ManifestTool
+		if err := json.Unmarshal(b, &t); err != nil {
+			return nil, err
+		}
+		m.Tools = append(m.Tools, t)
+	}
+	sort.Slice(m.Tools, func(i, j int) bool { return m.Tools[i].Name < m.Tools[j].Name })
+	return m, nil
+}
+
+// WriteManifest 以缩进 JSON 写出 manifest
+func WriteManifest(w io.Writer, m *Manifest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// LoadManifest 读取 WriteManifest 写出的文件
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// 工具变化的级别
+const (
+	ChangeBreaking   = "breaking"   // 按旧定义构造的调用可能失败或含义改变
+	ChangeCompatible = "compatible" // 旧调用仍然有效
+)
+
+// ManifestChange 是两个 manifest 之间的一处差异
+type ManifestChange struct {
+	Kind    string `json:"kind"`
+	Tool    string `json:"tool"`
+	Message string `json:"message"`
+}
+
+// DiffManifests 比较两个 manifest，报告新增、删除 (及可能的重命名) 的工具，
+// 以及参数的删除、新增必填、类型与枚举变化
+func DiffManifests(old, new *Manifest) []ManifestChange {
+	var changes []ManifestChange
+	oldTools, newTools := manifestIndex(old), manifestIndex(new)
+
+	var removed, added []string
+	for _, t := range old.Tools {
+		if _, ok := newTools[t.Name]; !ok {
+			removed = append(removed, t.Name)
+		}
+	}
+	for _, t := range new.Tools {
+		if _, ok := oldTools[t.Name]; !ok {
+			added = append(added, t.Name)
+		}
+	}
+	for _, name := range removed {
+		msg := "tool removed"
+		// 输入 schema 完全相同的新工具多半是重命名
+		for _, a := range added {
+			if reflect.DeepEqual(oldTools[name].InputSchema, newTools[a].InputSchema) {
+				msg = fmt.Sprintf("tool removed, possibly renamed to %s", a)
+				break
+			}
+		}
+		changes = append(changes, ManifestChange{Kind: ChangeBreaking, Tool: name, Message: msg})
+	}
+	for _, name := range added {
+		changes = append(changes, ManifestChange{Kind: ChangeCompatible, Tool: name, Message: "tool added"})
+	}
+
+	for _, t := range new.Tools {
+		prev, ok := oldTools[t.Name]
+		if !ok {
+			continue
+		}
+		report := func(kind, format string, a ...any) {
+			changes = append(changes, ManifestChange{Kind: kind, Tool: t.Name, Message: fmt.Sprintf(format, a...)})
+		}
+		n := len(changes)
+		diffSchema("", prev.InputSchema, t.InputSchema, report)
+		if len(changes) == n && !reflect.DeepEqual(prev.InputSchema, t.InputSchema) {
+			report(ChangeCompatible, "input schema changed")
+		}
+		if prev.Title != t.Title {
+			report(ChangeCompatible, "title changed")
+		}
+		if prev.Description != t.Description {
+			report(ChangeCompatible, "description changed")
+		}
+		if !reflect.DeepEqual(prev.OutputSchema, t.OutputSchema) {
+			report(ChangeCompatible, "output schema changed")
+		}
+		diffHint("readOnlyHint", prev.Annotations.ReadOnlyHint, t.Annotations.ReadOnlyHint, report)
+		diffHint("destructiveHint", prev.Annotations.DestructiveHint, t.Annotations.DestructiveHint, report)
+		diffHint("idempotentHint", prev.Annotations.IdempotentHint, t.Annotations.IdempotentHint, report)
+		diffHint("openWorldHint", prev.Annotations.OpenWorldHint, t.Annotations.OpenWorldHint, report)
+	}
+	return changes
+}
+
+func manifestIndex(m *Manifest) map[string]ManifestTool {
+	idx := make(map[string]ManifestTool, len(m.Tools))
+	for _, t := range m.Tools {
+		idx[t.Name] = t
+	}
+	return idx
+}
+
+// diffSchema 递归比较对象属性、数组元素与 oneOf/anyOf 分支，path 为参数路径，例如 body.items[].id
+func diffSchema(path string, old, new map[string]any, report func(kind, format string, a ...any)) {
+	if old == nil || new == nil {
+		return
+	}
+	name := path
+	if name == "" {
+		name = "input"
+	}
+	if ot, nt := old["type"], new["type"]; !reflect.DeepEqual(ot, nt) {
+		report(ChangeBreaking, "%s type changed from %v to %v", name, ot, nt)
+		return
+	}
+
+	oe, oldEnum := old["enum"].([]any)
+	ne, newEnum := new["enum"].([]any)
+	switch {
+	case newEnum && !oldEnum:
+		report(ChangeBreaking, "%s now only accepts %v", name, ne)
+	case oldEnum && !newEnum:
+		report(ChangeCompatible, "%s is no longer limited to %v", name, oe)
+	case oldEnum:
+		for _, v := range oe {
+			if !containsValue(ne, v) {
+				report(ChangeBreaking, "%s no longer accepts %v", name, v)
+			}
+		}
+		for _, v := range ne {
+			if !containsValue(oe, v) {
+				report(ChangeCompatible, "%s now also accepts %v", name, v)
+			}
+		}
+	}
+
+	oldProps, _ := old["properties"].(map[string]any)
+	newProps, _ := new["properties"].(map[string]any)
+	oldReq, newReq := stringList(old["required"]), stringList(new["required"])
+	for _, key := range sortedKeys(oldProps) {
+		if _, ok := newProps[key]; !ok {
+			report(ChangeBreaking, "argument %s removed", joinPath(path, key))
+		}
+	}
+	for _, key := range sortedKeys(newProps) {
+		child := joinPath(path, key)
+		op, existed := oldProps[key]
+		switch {
+		case !existed && slices.Contains(newReq, key):
+			report(ChangeBreaking, "required argument %s added", child)
+		case !existed:
+			report(ChangeCompatible, "optional argument %s added", child)
+		default:
+			if slices.Contains(newReq, key) && !slices.Contains(oldReq, key) {
+				report(ChangeBreaking, "argument %s is now required", child)
+			} else if !slices.Contains(newReq, key) && slices.Contains(oldReq, key) {
+				report(ChangeCompatible, "argument %s is now optional", child)
+			}
+			oc, _ := op.(map[string]any)
+			nc, _ := newProps[key].(map[string]any)
+			diffSchema(child, oc, nc, report)
+		}
+	}
+
+	oi, _ := old["items"].(map[string]any)
+	ni, _ := new["items"].(map[string]any)
+	diffSchema(name+"[]", oi, ni, report)
+
+	for _, kw := range []string{"oneOf", "anyOf"} {
+		diffBranches(path, kw, old[kw], new[kw], report)
+	}
+}
+
+// diffBranches 按 branchKey 对应新旧分支后逐个比较，例如分组工具中每个操作的分支
+func diffBranches(path, kw string, old, new any, report func(kind, format string, a ...any)) {
+	index := func(v any) (map[string]map[string]any, []string) {
+		list, _ := v.([]any)
+		m := make(map[string]map[string]any, len(list))
+		keys := make([]string, 0, len(list))
+		for i, x := range list {
+			b, _ := x.(map[string]any)
+			k := branchKey(b, i)
+			m[k] = b
+			keys = append(keys, k)
+		}
+		return m, keys
+	}
+	oldBranches, oldKeys := index(old)
+	newBranches, newKeys := index(new)
+	for _, k := range oldKeys {
+		if _, ok := newBranches[k]; !ok {
+			report(ChangeBreaking, "%s removed", joinPath(path, kw+"["+k+"]"))
+		}
+	}
+	for _, k := range newKeys {
+		child := joinPath(path, kw+"["+k+"]")
+		if ob, ok := oldBranches[k]; ok {
+			diffSchema(child, ob, newBranches[k], report)
+		} else {
+			report(ChangeCompatible, "%s added", child)
+		}
+	}
+}
+
+// branchKey 以分支中 const 属性的值标识分支，没有时使用下标
+func branchKey(branch map[string]any, i int) string {
+	props, _ := branch["properties"].(map[string]any)
+	for _, k := range sortedKeys(props) {
+		if p, ok := props[k].(map[string]any); ok {
+			if c, ok := p["const"]; ok {
+				return fmt.Sprint(c)
+			}
+		}
+	}
+	return strconv.Itoa(i)
+}
+
+func containsValue(list []any, v any) bool {
+	return slices.ContainsFunc(list, func(x any) bool { return reflect.DeepEqual(x, v) })
+}
+
+func diffHint(name string, old, new *bool, report func(kind, format string, a ...any)) {
+	if boolVal(old) != boolVal(new) {
+		report(ChangeCompatible, "annotation %s changed from %v to %v", name, boolVal(old), boolVal(new))
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func stringList(v any) []string {
+	list, _ := v.([]any)
+	out := make([]string, 0, len(list))
+	for _, x := range list {
+		if s, ok := x.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// schema 解析 JSON 形式的输入 schema
+func schema(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDiffManifests(t *testing.T) {
+	base := `{"type":"object","properties":{"id":{"type":"integer"},"status":{"type":"string","enum":["a","b"]},"q":{"type":"string"}},"required":["id"]}`
+	tagged := func(ops ...string) string {
+		branches := make([]any, 0, len(ops))
+		for _, op := range ops {
+			branches = append(branches, map[string]any{
+				"properties": map[string]any{
+					"operation": map[string]any{"const": op},
+					"arguments": map[string]any{"type": "object", "properties": map[string]any{"id": map[string]any{"type": "integer"}}},
+				},
+			})
+		}
+		b, _ := json.Marshal(map[string]any{"type": "object", "oneOf": branches})
+		return string(b)
+	}
+
+	tests := []struct {
+		name     string
+		old, new []ManifestTool
+		want     []ManifestChange
+	}{
+		{
+			name: "no changes",
+			old:  []ManifestTool{{Name: "getPet", InputSchema: schema(t, base)}},
+			new:  []ManifestTool{{Name: "getPet", InputSchema: schema(t, base)}},
+		},
+		{
+			name: "tool renamed",
+			old:  []ManifestTool{{Name: "getPet", InputSchema: schema(t, base)}},
+			new:  []ManifestTool{{Name: "fetchPet", InputSchema: schema(t, base)}},
+			want: []ManifestChange{
+				{Kind: ChangeBreaking, Tool: "getPet", Message: "tool removed, possibly renamed to fetchPet"},
+				{Kind: ChangeCompatible, Tool: "fetchPet", Message: "tool added"},
+			},
+		},
+		{
+			name: "arguments added and removed",
+			old:  []ManifestTool{{Name: "getPet", InputSchema: schema(t, base)}},
+			new: []ManifestTool{{Name: "getPet", InputSchema: schema(t,
+				`{"type":"object","properties":{"id":{"type":"integer"},"status":{"type":"string","enum":["a","b"]},"owner":{"type":"string"},"page":{"type":"integer"}},"required":["id","owner"]}`)}},
+			want: []ManifestChange{
+				{Kind: ChangeBreaking, Tool: "getPet", Message: "argument q removed"},
+				{Kind: ChangeBreaking, Tool: "getPet", Message: "required argument owner added"},
+				{Kind: ChangeCompatible, Tool: "getPet", Message: "optional argument page added"},
+			},
+		},
+		{
+			name: "type changed and argument now required",
+			old:  []ManifestTool{{Name: "getPet", InputSchema: schema(t, base)}},
+			new: []ManifestTool{{Name: "getPet", InputSchema: schema(t,
+				`{"type":"object","properties":{"id":{"type":"string"},"status":{"type":"string","enum":["a","b"]},"q":{"type":"string"}},"required":["id","q"]}`)}},
+			want: []ManifestChange{
+				{Kind: ChangeBreaking, Tool: "getPet", Message: "id type changed from integer to string"},
+				{Kind: ChangeBreaking, Tool: "getPet", Message: "argument q is now required"},
+			},
+		},
+		{
+			name: "enum narrowed, widened and added",
+			old:  []ManifestTool{{Name: "getPet", InputSchema: schema(t, base)}},
+			new: []ManifestTool{{Name: "getPet", InputSchema: schema(t,
+				`{"type":"object","properties":{"id":{"type":"integer"},"status":{"type":"string","enum":["b","c"]},"q":{"type":"string","enum":["x"]}},"required":["id"]}`)}},
+			want: []ManifestChange{
+				{Kind: ChangeBreaking, Tool: "getPet", Message: "q now only accepts [x]"},
+				{Kind: ChangeBreaking, Tool: "getPet", Message: "status no longer accepts a"},
+				{Kind: ChangeCompatible, Tool: "getPet", Message: "status now also accepts c"},
+			},
+		},
+		{
+			name: "enum removed",
+			old:  []ManifestTool{{Name: "getPet", InputSchema: schema(t, base)}},
+			new: []ManifestTool{{Name: "getPet", InputSchema: schema(t,
+				`{"type":"object","properties":{"id":{"type":"integer"},"status":{"type":"string"},"q":{"type":"string"}},"required":["id"]}`)}},
+			want: []ManifestChange{
+				{Kind: ChangeCompatible, Tool: "getPet", Message: "status is no longer limited to [a b]"},
+			},
+		},
+		{
+			name: "tag tool operations added and removed",
+			old:  []ManifestTool{{Name: "pets", InputSchema: schema(t, tagged("getPet", "deletePet"))}},
+			new:  []ManifestTool{{Name: "pets", InputSchema: schema(t, tagged("getPet", "listPets"))}},
+			want: []ManifestChange{
+				{Kind: ChangeBreaking, Tool: "pets", Message: "oneOf[deletePet] removed"},
+				{Kind: ChangeCompatible, Tool: "pets", Message: "oneOf[listPets] added"},
+			},
+		},
+		{
+			name: "tag tool branch argument changed",
+			old:  []ManifestTool{{Name: "pets", InputSchema: schema(t, tagged("getPet"))}},
+			new: []ManifestTool{{Name: "pets", InputSchema: schema(t,
+				`{"type":"object","oneOf":[{"properties":{"operation":{"const":"getPet"},"arguments":{"type":"object","properties":{}}}}]}`)}},
+			want: []ManifestChange{
+				{Kind: ChangeBreaking, Tool: "pets", Message: "argument oneOf[getPet].arguments.id removed"},
+			},
+		},
+		{
+			name: "metadata changes are compatible",
+			old:  []ManifestTool{{Name: "getPet", Description: "old", InputSchema: schema(t, base)}},
+			new: []ManifestTool{{Name: "getPet", Description: "new", InputSchema: schema(t, base),
+				Annotations: mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(true)}}},
+			want: []ManifestChange{
+				{Kind: ChangeCompatible, Tool: "getPet", Message: "description changed"},
+				{Kind: ChangeCompatible, Tool: "getPet", Message: "annotation readOnlyHint changed from false to true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffManifests(&Manifest{Tools: tt.old}, &Manifest{Tools: tt.new})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("changes:\n got  %+v\n want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	s := server.NewMCPServer("t", "1")
+	if err := NewConverter(loadTestDoc(t, testSpec), WithBaseURL("http://localhost")).Register(s); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManifest(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	var loaded Manifest
+	if err := json.Unmarshal(buf.Bytes(), &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if changes := DiffManifests(m, &loaded); len(changes) > 0 {
+		t.Errorf("manifest changed after round trip: %+v", changes)
+	}
+	names := make([]string, 0, len(loaded.Tools))
+	for _, tool := range loaded.Tools {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"createPet", "getPet", "listPets"}; !slices.Equal(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 元工具名
+const (
+	MetaToolSearch   = "search_operations"
+	MetaToolDescribe = "describe_operation"
+	MetaToolCall     = "call_operation"
+)
+
+const (
+	searchDefaultLimit = 10
+	bm25K1             = 1.2
+	bm25B              = 0.75
+)
+
+// stem 去掉英文复数词尾，使 pet 与 pets 匹配
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// tokenize 按非字母数字与驼峰拆分，驼峰词同时保留整体
+func tokenize(s string) []string {
+	var out []string
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		out = append(out, stem(strings.ToLower(word)))
+
+		start := 0
+		runes := []rune(word)
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) {
+				out = append(out, stem(strings.ToLower(string(runes[start:i]))))
+				start = i
+			}
+		}
+		if start > 0 {
+			out = append(out, stem(strings.ToLower(string(runes[start:]))))
+		}
+	}
+	return out
+}
+
+// searchIndex 是操作的 BM25 索引，工具名、summary、标签与路径的权重高于描述
+type searchIndex struct {
+	tools  []operationTool
+	terms  []map[string]int
+	length []int
+	avgLen float64
+	df     map[string]int
+}
+
+func newSearchIndex(tools []operationTool) *searchIndex {
+	idx := &searchIndex{tools: tools, df: map[string]int{}}
+	total := 0
+	for _, t := range tools {
+		var tokens []string
+		for range 3 {
+			tokens = append(tokens, tokenize(t.name)...)
+		}
+		for range 2 {
+			tokens = append(tokens, tokenize(t.op.OperationId)...)
+			tokens = append(tokens, tokenize(t.op.Summary)...)
+			tokens = append(tokens, tokenize(strings.Join(t.op.Tags, " "))...)
+			tokens = append(tokens, tokenize(t.path)...)
+		}
+		tokens = append(tokens, tokenize(t.op.Description)...)
+		tokens = append(tokens, strings.ToLower(t.method))
+
+		tf := map[string]int{}
+		for _, tok := range tokens {
+			tf[tok]++
+		}
+		for tok := range tf {
+			idx.df[tok]++
+		}
+		idx.terms = append(idx.terms, tf)
+		idx.length = append(idx.length, len(tokens))
+		total += len(tokens)
+	}
+	if len(tools) > 0 {
+		idx.avgLen = float64(total) / float64(len(tools))
+	}
+	return idx
+}
+
+type searchHit struct {
+	Name    string   `json:"name"`
+	Method  string   `json:"method"`
+	Path    string   `json:"path"`
+	Summary string   `json:"summary,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Score   float64  `json:"score"`
+}
+
+func (idx *searchIndex) search(query, tag string, limit int) []searchHit {
+	queryTerms := tokenize(query)
+	n := float64(len(idx.tools))
+
+	var hits []searchHit
+	for i, t := range idx.tools {
+		if tag != "" && !containsFold(t.op.Tags, tag) {
+			continue
+		}
+		score := 0.0
+		for _, q := range queryTerms {
+			f := float64(idx.terms[i][q])
+			if f == 0 {
+				continue
+			}
+			df := float64(idx.df[q])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(idx.length[i])/idx.avgLen
+			score += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+		}
+		if score == 0 && len(queryTerms) > 0 {
+			continue
+		}
+		hits = append(hits, searchHit{
+			Name:    t.name,
+			Method:  t.method,
+			Path:    t.path,
+			Summary: t.op.Summary,
+			Tags:    t.op.Tags,
+			Score:   math.Round(score*1000) / 1000,
+		})
+	}
+	slices.SortStableFunc(hits, func(a, b searchHit) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// addMetaTools 只注册搜索、查看与调用操作的三个工具，适用于操作数量很多的文档
+func addMetaTools(mcpServer ToolRegistry, tools []operationTool) {
+	idx := newSearchIndex(tools)
+	byName := make(map[string]operationTool, len(tools))
+	for _, t := range tools {
+		byName[t.name] = t
+	}
+	lookup := func(request mcp.CallToolRequest) (operationTool, *mcp.CallToolResult) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return operationTool{}, mcp.NewToolResultError(err.Error())
+		}
+		t, ok := byName[name]
+		if !ok {
+			return operationTool{}, mcp.NewToolResultError(
+				fmt.Sprintf("unknown operation %q, use %s to find operations", name, MetaToolSearch))
+		}
+		return t, nil
+	}
+
+	mcpServer.AddTool(mcp.NewTool(MetaToolSearch,
+		mcp.WithDescription(fmt.Sprintf("Search the %d operations of this API by keywords. "+
+			"Returns operation names ranked by relevance; use %s for details and %s to invoke one.",
+			len(tools), MetaToolDescribe, MetaToolCall)),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			ReadOnlyHint:   mcp.ToBoolPtr(true),
+			IdempotentHint: mcp.ToBoolPtr(true),
+			OpenWorldHint:  mcp.ToBoolPtr(false),
+		}),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Keywords matched against operation names, summaries, tags and paths")),
+		mcp.WithString("tag", mcp.Description("Only return operations with this tag")),
+		mcp.WithNumber("limit", mcp.Min(1), mcp.Max(100), withDefault(searchDefaultLimit)),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, err := request.RequireString("query")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		hits := idx.search(query, request.GetString("tag", ""), request.GetInt("limit", searchDefaultLimit))
+
+		var sb strings.Builder
+		if len(hits) == 0 {
+			sb.WriteString("no matching operations")
+		}
+		for _, h := range hits {
+			fmt.Fprintf(&sb, "%s: %s %s", h.Name, h.Method, h.Path)
+			if h.Summary != "" {
+				sb.WriteString(" - " + h.Summary)
+			}
+			sb.WriteString("\n")
+		}
+		return mcp.NewToolResultStructured(map[string]any{"operations": hits}, sb.String()), nil
+	})
+
+	mcpServer.AddTool(mcp.NewTool(MetaToolDescribe,
+		mcp.WithDescription(fmt.Sprintf("Show the documentation and input schema of an operation found with %s.", MetaToolSearch)),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			ReadOnlyHint:   mcp.ToBoolPtr(true),
+			IdempotentHint: mcp.ToBoolPtr(true),
+			OpenWorldHint:  mcp.ToBoolPtr(false),
+		}),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Operation name")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		t, res := lookup(request)
+		if res != nil {
+			return res, nil
+		}
+		schema, err := json.MarshalIndent(t.tool.InputSchema, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		text := operationDoc(t.path, t.method, t.op, t.item) +
+			fmt.Sprintf("## Input Schema\n\nPass these as `arguments` to %s:\n\n```json\n%s\n```\n", MetaToolCall, schema)
+		return mcp.NewToolResultText(text), nil
+	})
+
+	mcpServer.AddTool(mcp.NewTool(MetaToolCall,
+		mcp.WithDescription(fmt.Sprintf("Invoke an operation by name. Check its input schema with %s first.", MetaToolDescribe)),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			ReadOnlyHint:    mcp.ToBoolPtr(false),
+			DestructiveHint: mcp.ToBoolPtr(true),
+			IdempotentHint:  mcp.ToBoolPtr(false),
+			OpenWorldHint:   mcp.ToBoolPtr(true),
+		}),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Operation name")),
+		mcp.WithObject("arguments", mcp.Description("Arguments matching the operation's input schema")),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		t, res := lookup(request)
+		if res != nil {
+			return res, nil
+		}
+		args, _ := request.GetArguments()["arguments"].(map[string]any)
+		return t.invoke(ctx, args)
+	})
+}
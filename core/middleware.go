@@ -44,14 +44,15 @@ func (m *LoggingMiddleware) ResourceMiddleware(next server.ResourceHandlerFunc)
 }
 
 type RateLimitMiddleware struct {
-	rate, burst int
-	limiters    map[string]*rate.Limiter
-	mutex       sync.RWMutex
+	rate     rate.Limit
+	burst    int
+	limiters map[string]*rate.Limiter
+	mutex    sync.RWMutex
 }
 
 func NewRateLimitMiddleware(rps float64, burst int) *RateLimitMiddleware {
 	return &RateLimitMiddleware{
-		rate:     int(rps),
+		rate:     rate.Limit(rps),
 		burst:    burst,
 		limiters: map[string]*rate.Limiter{},
 	}
@@ -66,7 +67,10 @@ func (m *RateLimitMiddleware) limiter(id string) *rate.Limiter {
 	}
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	l = rate.NewLimiter(rate.Limit(m.rate), m.burst)
+	if l, ok := m.limiters[id]; ok {
+		return l
+	}
+	l = rate.NewLimiter(m.rate, m.burst)
 	m.limiters[id] = l
 	return l
 }
@@ -82,3 +86,11 @@ func (m *RateLimitMiddleware) ToolMiddleware(next server.ToolHandlerFunc) server
 		return next(ctx, r)
 	}
 }
+
+// Wait 阻塞直到会话获得令牌，批量调用中的每一项都经过它限流
+func (m *RateLimitMiddleware) Wait(ctx context.Context) error {
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		return m.limiter(cs.SessionID()).Wait(ctx)
+	}
+	return nil
+}
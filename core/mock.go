@@ -0,0 +1,378 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	v3base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// ArgMockStatus 在 mock 模式下选择返回的响应状态码，例如 "404"
+const ArgMockStatus = "_mock_status"
+
+// mockMaxDepth 限制递归 schema 的展开深度
+const mockMaxDepth = 6
+
+func nodeValue(n *yaml.Node) (any, bool) {
+	if n == nil {
+		return nil, false
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// pickResponse 按指定状态码、首个 2xx、default 的顺序选择响应
+func pickResponse(op *v3high.Operation, status string) (string, *v3high.Response) {
+	if op.Responses == nil {
+		return "", nil
+	}
+	var codes []string
+	if op.Responses.Codes != nil {
+		for el := op.Responses.Codes.First(); el != nil; el = el.Next() {
+			codes = append(codes, el.Key())
+		}
+	}
+	get := func(code string) *v3high.Response {
+		r, _ := op.Responses.Codes.Get(code)
+		return r
+	}
+
+	if status != "" {
+		if slices.Contains(codes, status) {
+			return status, get(status)
+		}
+		if op.Responses.Default != nil {
+			return status, op.Responses.Default
+		}
+		return status, nil
+	}
+
+	slices.Sort(codes)
+	for _, c := range codes {
+		if strings.HasPrefix(c, "2") {
+			return c, get(c)
+		}
+	}
+	if op.Responses.Default != nil {
+		return "200", op.Responses.Default
+	}
+	if len(codes) > 0 {
+		return codes[0], get(codes[0])
+	}
+	return "", nil
+}
+
+// pickMediaType 优先选择 JSON 响应
+func pickMediaType(content *orderedmap.Map[string, *v3high.MediaType]) *v3high.MediaType {
+	if content == nil {
+		return nil
+	}
+	if mt, ok := content.Get("application/json"); ok {
+		return mt
+	}
+	for el := content.First(); el != nil; el = el.Next() {
+		if strings.HasSuffix(el.Key(), "+json") {
+			return el.Value()
+		}
+	}
+	if el := content.First(); el != nil {
+		return el.Value()
+	}
+	return nil
+}
+
+// mediaTypeExample 依次使用 example、examples 和 schema 生成示例值
+func mediaTypeExample(mt *v3high.MediaType) (any, bool) {
+	if mt == nil {
+		return nil, false
+	}
+	if v, ok := nodeValue(mt.Example); ok {
+		return v, true
+	}
+	if mt.Examples != nil {
+		for el := mt.Examples.First(); el != nil; el = el.Next() {
+			if ex := el.Value(); ex != nil {
+				if v, ok := nodeValue(ex.Value); ok {
+					return v, true
+				}
+			}
+		}
+	}
+	if mt.Schema != nil {
+		return synthesizeValue(mt.Schema.Schema(), 0), true
+	}
+	return nil, false
+}
+
+// synthesizeValue 根据 schema 生成符合约束的示例数据
+func synthesizeValue(s *v3base.Schema, depth int) any {
+	if s == nil || depth > mockMaxDepth {
+		return nil
+	}
+	if v, ok := nodeValue(s.Example); ok {
+		return v
+	}
+	for _, ex := range s.Examples {
+		if v, ok := nodeValue(ex); ok {
+			return v
+		}
+	}
+	if v, ok := nodeValue(s.Default); ok {
+		return v
+	}
+	if v, ok := nodeValue(s.Const); ok {
+		return v
+	}
+	if len(s.Enum) > 0 {
+		if v, ok := nodeValue(s.Enum[0]); ok {
+			return v
+		}
+	}
+
+	if len(s.AllOf) > 0 {
+		out := map[string]any{}
+		for _, sp := range s.AllOf {
+			if m, ok := synthesizeValue(sp.Schema(), depth+1).(map[string]any); ok {
+				for k, v := range m {
+					out[k] = v
+				}
+			}
+		}
+		return out
+	}
+	for _, alts := range [][]*v3base.SchemaProxy{s.OneOf, s.AnyOf} {
+		if len(alts) > 0 {
+			return synthesizeValue(alts[0].Schema(), depth+1)
+		}
+	}
+
+	t := firstType(s.Type)
+	if t == "" && s.Properties != nil {
+		t = "object"
+	}
+	switch t {
+	case "object":
+		out := map[string]any{}
+		if s.Properties != nil {
+			for el := s.Properties.First(); el != nil; el = el.Next() {
+				if v := synthesizeValue(el.Value().Schema(), depth+1); v != nil {
+					out[el.Key()] = v
+				}
+			}
+		}
+		return out
+	case "array":
+		var items []any
+		if s.Items != nil && s.Items.IsA() {
+			if v := synthesizeValue(s.Items.A.Schema(), depth+1); v != nil {
+				items = append(items, v)
+			}
+		}
+		if items == nil {
+			items = []any{}
+		}
+		return items
+	case "integer":
+		return synthesizeInteger(s)
+	case "number":
+		return synthesizeNumber(s)
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	}
+	return synthesizeString(s)
+}
+
+// numberBounds 返回 minimum/maximum 及其是否排除边界，兼容 3.0 的布尔值与 3.1 的数值写法
+func numberBounds(s *v3base.Schema) (lo, hi *float64, loEx, hiEx bool) {
+	lo, hi = s.Minimum, s.Maximum
+	if d := s.ExclusiveMinimum; d != nil {
+		if d.IsB() {
+			lo, loEx = &d.B, true
+		} else {
+			loEx = d.A
+		}
+	}
+	if d := s.ExclusiveMaximum; d != nil {
+		if d.IsB() {
+			hi, hiEx = &d.B, true
+		} else {
+			hiEx = d.A
+		}
+	}
+	return lo, hi, loEx, hiEx
+}
+
+func synthesizeInteger(s *v3base.Schema) int64 {
+	lo, hi, loEx, hiEx := numberBounds(s)
+	v := int64(1)
+	if lo != nil {
+		v = int64(math.Ceil(*lo))
+		if loEx && float64(v) == *lo {
+			v++
+		}
+	}
+	if hi != nil && float64(v) > *hi || hi != nil && hiEx && float64(v) == *hi {
+		v = int64(math.Floor(*hi))
+		if hiEx && float64(v) == *hi {
+			v--
+		}
+	}
+	return v
+}
+
+func synthesizeNumber(s *v3base.Schema) float64 {
+	lo, hi, loEx, hiEx := numberBounds(s)
+	switch {
+	case lo != nil && hi != nil:
+		if (loEx || hiEx) && *lo < *hi {
+			return *lo + (*hi-*lo)/2
+		}
+		return *lo
+	case lo != nil:
+		if loEx {
+			return *lo + 1
+		}
+		return *lo
+	case hi != nil:
+		if hiEx || *hi < 1.5 {
+			return min(*hi-1, 1.5)
+		}
+	}
+	return 1.5
+}
+
+func synthesizeString(s *v3base.Schema) string {
+	switch s.Format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00Z"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-4000-8000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "ZXhhbXBsZQ=="
+	}
+	if s.Pattern != "" {
+		if out, ok := patternString(s.Pattern); ok {
+			return out
+		}
+	}
+	out := "string"
+	if s.MinLength != nil && int(*s.MinLength) > len(out) {
+		out = strings.Repeat("x", int(*s.MinLength))
+	}
+	if s.MaxLength != nil && int(*s.MaxLength) < len(out) {
+		out = out[:max(*s.MaxLength, 0)]
+	}
+	return out
+}
+
+// patternString 生成匹配正则的最短字符串，正则无法解析或生成结果不匹配时返回 false
+func patternString(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var sb strings.Builder
+	writePattern(&sb, re)
+	out := sb.String()
+	if ok, _ := regexp.MatchString(pattern, out); !ok {
+		return "", false
+	}
+	return out, true
+}
+
+func writePattern(sb *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		sb.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) > 0 {
+			sb.WriteRune(re.Rune[0])
+		}
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteByte('x')
+	case syntax.OpCapture:
+		writePattern(sb, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writePattern(sb, sub)
+		}
+	case syntax.OpAlternate:
+		writePattern(sb, re.Sub[0])
+	case syntax.OpPlus:
+		writePattern(sb, re.Sub[0])
+	case syntax.OpRepeat:
+		for range re.Min {
+			writePattern(sb, re.Sub[0])
+		}
+	}
+	// 其余 (星号、问号、锚点等) 取空串
+}
+
+// mockBody 返回操作在指定状态码下的示例响应体 (JSON) 及实际使用的状态码
+func mockBody(op *v3high.Operation, status string) (string, string, error) {
+	if op == nil {
+		return "", "", fmt.Errorf("mock: operation definition not available")
+	}
+	code, resp := pickResponse(op, status)
+	if resp == nil {
+		return code, "", fmt.Errorf("mock: no response defined for status %q", code)
+	}
+
+	body, ok := mediaTypeExample(pickMediaType(resp.Content))
+	if !ok {
+		return code, "", nil
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return code, "", fmt.Errorf("mock: %w", err)
+	}
+	return code, string(b), nil
+}
+
+// mockResult 返回操作的示例响应而不访问上游，与真实调用一样 4xx/5xx 标记为错误
+func mockResult(op *v3high.Operation, reserved map[string]any) *mcp.CallToolResult {
+	status := ""
+	switch v := reserved[ArgMockStatus].(type) {
+	case string:
+		status = v
+	case float64:
+		status = strconv.Itoa(int(v))
+	}
+
+	code, body, err := mockBody(op, status)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	res := mcp.NewToolResultText(body)
+	res.IsError = strings.HasPrefix(code, "4") || strings.HasPrefix(code, "5")
+	return res
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	v3base "github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// loadSchema 把 YAML 形式的 schema 放进 3.1 文档的 components 中解析
+func loadSchema(t *testing.T, src string) *v3base.Schema {
+	t.Helper()
+	doc := loadTestDoc(t, "openapi: 3.1.0\ninfo: {title: t, version: '1'}\npaths: {}\ncomponents:\n  schemas:\n    S:\n"+indent(src, "      "))
+	sp, ok := doc.Model.Components.Schemas.Get("S")
+	if !ok {
+		t.Fatal("schema not loaded")
+	}
+	return sp.Schema()
+}
+
+func indent(s, prefix string) string {
+	return regexp.MustCompile(`(?m)^`).ReplaceAllString(s, prefix)
+}
+
+func TestSynthesizeValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string // JSON，为空时只检查 pattern
+		match  string
+	}{
+		{name: "string", schema: "type: string", want: `"string"`},
+		{name: "maxLength", schema: "type: string\nmaxLength: 3", want: `"str"`},
+		{name: "minLength", schema: "type: string\nminLength: 8", want: `"xxxxxxxx"`},
+		{name: "pattern", schema: "type: string\npattern: '^[A-Z]{3}-\\d{2,4}$'", match: `^[A-Z]{3}-\d{2,4}$`},
+		{name: "pattern alternation", schema: "type: string\npattern: '^(draft|published)$'", want: `"draft"`},
+		{name: "format wins over pattern", schema: "type: string\nformat: date\npattern: '^x$'", want: `"2024-01-01"`},
+		{name: "integer", schema: "type: integer", want: `1`},
+		{name: "integer maximum", schema: "type: integer\nmaximum: 0", want: `0`},
+		{name: "integer exclusive maximum", schema: "type: integer\nexclusiveMaximum: 0", want: `-1`},
+		{name: "integer minimum", schema: "type: integer\nminimum: 10\nmaximum: 20", want: `10`},
+		{name: "integer exclusive minimum", schema: "type: integer\nexclusiveMinimum: 10", want: `11`},
+		{name: "number maximum", schema: "type: number\nmaximum: 1", want: `0`},
+		{name: "number exclusive range", schema: "type: number\nexclusiveMinimum: 0\nexclusiveMaximum: 1", want: `0.5`},
+		{name: "enum", schema: "type: integer\nenum: [5, 6]\nmaximum: 0", want: `5`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := synthesizeValue(loadSchema(t, tt.schema), 0)
+			b, _ := json.Marshal(v)
+			if tt.want != "" && string(b) != tt.want {
+				t.Errorf("value = %s, want %s", b, tt.want)
+			}
+			if tt.match != "" {
+				s, _ := v.(string)
+				if !regexp.MustCompile(tt.match).MatchString(s) {
+					t.Errorf("value %q does not match %s", s, tt.match)
+				}
+			}
+		})
+	}
+}
+
+func TestMockResultStatus(t *testing.T) {
+	doc := loadTestDoc(t, `
+openapi: 3.0.3
+info: {title: t, version: '1'}
+paths:
+  /pets/{id}:
+    get:
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              example: {id: 1}
+        '404':
+          description: missing
+          content:
+            application/json:
+              example: {error: not found}
+`)
+	item, _ := doc.Model.Paths.PathItems.Get("/pets/{id}")
+	tests := []struct {
+		status    any
+		wantError bool
+	}{
+		{nil, false},
+		{"200", false},
+		{"404", true},
+		{float64(500), true}, // 未定义的状态码
+	}
+	for _, tt := range tests {
+		res := mockResult(item.Get, map[string]any{ArgMockStatus: tt.status})
+		if res.IsError != tt.wantError {
+			t.Errorf("status %v: IsError = %v, want %v (%s)", tt.status, res.IsError, tt.wantError, resultText(res))
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/constellation39/openapi-to-mcp/core/session"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// Option 配置 AddToolFromOpenAPI 与 NewToolHandlerFromOp 的可选行为
+type Option func(*options)
+
+type options struct {
+	baseURL       string            // 覆盖文档中的 servers
+	headers       map[string]string // 每个上游请求附带的请求头
+	client        *http.Client      // 无会话或未启用 Cookie 时使用
+	sessions      *session.Manager  // 提供会话各自的 Client，nil 时不区分会话
+	credentials   CredentialProvider
+	hooks         Hooks
+	serverName    string            // 选择 servers 中的条目
+	serverVars    map[string]string // 覆盖 server variables
+	specURL       string            // OpenAPI 文档来源，用于解析相对 server URL
+	pool          *UpstreamPool     // 设置后忽略静态基础地址
+	filter        ToolFilter
+	logger        *log.Logger
+	cookies       bool   // 使用会话各自的 Client 保存 Cookie
+	authorization string // 需要 HTTP Basic 认证的操作使用的 Authorization 头
+
+	destructiveMethods []string // 标记为 destructiveHint 的 HTTP 方法
+	confirm            ConfirmPolicy
+	dryRun             bool // 只返回构造好的请求，不发送
+	mock               bool // 使用文档中的示例响应代替上游
+	resourceTemplates  bool // 同时把带路径参数的 GET 操作注册为资源模板
+	completer          *Completer
+	toolMode           string
+	workflows          []Workflow
+	batchConcurrency   int // 大于 0 时注册批量调用工具
+	rateLimiter        *RateLimitMiddleware
+	links              bool                 // 根据响应 links 推荐后续调用
+	transforms         map[string]Transform // 工具名 -> 请求与响应转换
+
+	// 单个操作的参数取值，来自 x-mcp-default / x-mcp-fixed
+	toolName      string
+	paramDefaults map[string]any
+	paramFixed    map[string]any
+	needConfirm   bool
+	requiredArgs  []requiredArg
+	operation     *v3high.Operation
+	responseLinks map[string][]responseLink
+	transform     *compiledTransform
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		client:  http.DefaultClient,
+		logger:  log.New(io.Discard, "", 0),
+		cookies: true,
+
+		destructiveMethods: defaultDestructiveMethods,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithHTTPClient 指定默认的上游 HTTP Client
+func WithHTTPClient(cli *http.Client) Option {
+	return func(o *options) {
+		if cli != nil {
+			o.client = cli
+		}
+	}
+}
+
+// WithTransport 使用给定的 RoundTripper 发送上游请求
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		if rt != nil {
+			o.client = &http.Client{Transport: rt}
+		}
+	}
+}
+
+// WithBaseURL 设置上游基础地址，覆盖文档中的 servers
+func WithBaseURL(u string) Option {
+	return func(o *options) {
+		o.baseURL = u
+	}
+}
+
+// WithHeaders 设置每个上游请求附带的请求头
+func WithHeaders(headers map[string]string) Option {
+	return func(o *options) {
+		o.headers = headers
+	}
+}
+
+// WithSessions 启用 Cookie 时使用 m 中会话各自的 Client，会话由调用方在 MCP 会话开始与结束时创建和删除
+func WithSessions(m *session.Manager) Option {
+	return func(o *options) {
+		o.sessions = m
+	}
+}
+
+// CredentialProvider 在每个上游请求发送前设置凭据，例如按会话取得的 token
+type CredentialProvider func(ctx context.Context, req *http.Request) error
+
+// WithCredentials 设置上游请求的凭据来源
+func WithCredentials(p CredentialProvider) Option {
+	return func(o *options) {
+		o.credentials = p
+	}
+}
+
+// Hooks 在每个上游请求发送前与收到响应后调用，tool 为发起请求的工具名，返回错误时本次调用失败
+type Hooks struct {
+	BeforeRequest func(ctx context.Context, tool string, req *http.Request) error
+	AfterResponse func(ctx context.Context, tool string, resp *http.Response) error
+}
+
+// WithHooks 设置上游请求的 hooks
+func WithHooks(h Hooks) Option {
+	return func(o *options) {
+		o.hooks = h
+	}
+}
+
+// WithServer 按 description、x-name 或下标选择 OpenAPI servers 中的条目，名称不存在时构建工具返回错误
+func WithServer(name string) Option {
+	return func(o *options) {
+		o.serverName = name
+	}
+}
+
+// WithServerVariables 覆盖 server URL 模板中变量的默认值
+func WithServerVariables(vars map[string]string) Option {
+	return func(o *options) {
+		o.serverVars = vars
+	}
+}
+
+// WithSpecURL 设置 OpenAPI 文档的来源地址，相对 server URL 基于它解析
+func WithSpecURL(src string) Option {
+	return func(o *options) {
+		o.specURL = src
+	}
+}
+
+// WithUpstreamPool 通过上游池发送请求，实现多地址负载与故障转移
+func WithUpstreamPool(pool *UpstreamPool) Option {
+	return func(o *options) {
+		o.pool = pool
+	}
+}
+
+// WithToolFilter 只注册满足过滤规则的操作
+func WithToolFilter(f ToolFilter) Option {
+	return func(o *options) {
+		o.filter = f
+	}
+}
+
+// WithLogger 设置启动报告等日志的输出
+func WithLogger(l *log.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
+
+func withParamValues(defaults, fixed map[string]any) Option {
+	return func(o *options) {
+		o.paramDefaults = defaults
+		o.paramFixed = fixed
+	}
+}
+
+// WithDestructiveMethods 设置哪些 HTTP 方法的工具标记为 destructiveHint，默认只有 DELETE
+func WithDestructiveMethods(methods ...string) Option {
+	return func(o *options) {
+		if len(methods) > 0 {
+			o.destructiveMethods = methods
+		}
+	}
+}
+
+// WithConfirmPolicy 设置调用前需要用户确认的操作
+func WithConfirmPolicy(p ConfirmPolicy) Option {
+	return func(o *options) {
+		o.confirm = p
+	}
+}
+
+func withNeedConfirm(b bool) Option {
+	return func(o *options) {
+		o.needConfirm = b
+	}
+}
+
+func withRequiredArgs(args []requiredArg) Option {
+	return func(o *options) {
+		o.requiredArgs = args
+	}
+}
+
+// WithDryRun 开启后所有调用只返回构造好的请求 (URL、脱敏后的请求头、请求体与 curl 命令)
+func WithDryRun(b bool) Option {
+	return func(o *options) {
+		o.dryRun = b
+	}
+}
+
+// WithMockUpstream 开启后不访问上游，返回操作的响应示例或根据 schema 生成的数据
+func WithMockUpstream(b bool) Option {
+	return func(o *options) {
+		o.mock = b
+	}
+}
+
+// WithResourceTemplates 将带路径参数的 GET 操作额外注册为 MCP 资源模板
+func WithResourceTemplates(b bool) Option {
+	return func(o *options) {
+		o.resourceTemplates = b
+	}
+}
+
+// WithCompleter 将参数的候选值登记到 Completer，供 MCP completion 使用
+func WithCompleter(c *Completer) Option {
+	return func(o *options) {
+		o.completer = c
+	}
+}
+
+// 工具暴露方式
+const (
+	ToolModeOperations = "operations" // 每个操作注册为一个工具
+	ToolModeMeta       = "meta"       // 只注册搜索、查看与调用操作的元工具
+	ToolModeTags       = "tags"       // 每个标签一个工具，通过 operation 字段选择操作
+)
+
+// WithToolMode 选择操作暴露为工具的方式，默认为 ToolModeOperations
+func WithToolMode(mode string) Option {
+	return func(o *options) {
+		o.toolMode = mode
+	}
+}
+
+// WithWorkflows 将 workflow 注册为由多个操作组成的工具
+func WithWorkflows(workflows []Workflow) Option {
+	return func(o *options) {
+		o.workflows = workflows
+	}
+}
+
+// WithBatchTool 注册批量调用工具，concurrency 为同时执行的最大数量，0 表示不注册
+func WithBatchTool(concurrency int) Option {
+	return func(o *options) {
+		o.batchConcurrency = concurrency
+	}
+}
+
+// WithRateLimiter 让批量调用的每一项都按会话限流
+func WithRateLimiter(m *RateLimitMiddleware) Option {
+	return func(o *options) {
+		o.rateLimiter = m
+	}
+}
+
+// WithLinks 开启后，响应中声明的 links 写入工具描述，调用结果附带参数已填充的后续调用
+func WithLinks(enabled bool) Option {
+	return func(o *options) {
+		o.links = enabled
+	}
+}
+
+func withResponseLinks(links map[string][]responseLink) Option {
+	return func(o *options) {
+		o.responseLinks = links
+	}
+}
+
+// WithCookies 为 false 时所有会话共用默认 Client，不保存 Cookie
+func WithCookies(enabled bool) Option {
+	return func(o *options) {
+		o.cookies = enabled
+	}
+}
+
+// WithAuthorization 设置需要 HTTP Basic 认证的操作使用的 Authorization 头
+func WithAuthorization(value string) Option {
+	return func(o *options) {
+		o.authorization = value
+	}
+}
+
+func withToolName(name string) Option {
+	return func(o *options) {
+		o.toolName = name
+	}
+}
+
+// WithTransforms 设置操作的请求与响应转换，覆盖文档中同一操作的 x-mcp-transform
+func WithTransforms(transforms []Transform) Option {
+	return func(o *options) {
+		o.transforms = make(map[string]Transform, len(transforms))
+		for _, t := range transforms {
+			o.transforms[t.Operation] = t
+		}
+	}
+}
+
+func withTransform(t *compiledTransform) Option {
+	return func(o *options) {
+		o.transform = t
+	}
+}
+
+func withOperation(op *v3high.Operation) Option {
+	return func(o *options) {
+		o.operation = op
+	}
+}
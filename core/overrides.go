@@ -0,0 +1,122 @@
+package core
+
+import (
+	"fmt"
+
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/speakeasy-api/jsonpath/pkg/overlay"
+	"gopkg.in/yaml.v3"
+)
+
+// 操作与参数上支持的 x-mcp-* 扩展
+const (
+	extName        = "x-mcp-name"        // operation: 工具名
+	extDescription = "x-mcp-description" // operation: 工具描述
+	extEnabled     = "x-mcp-enabled"     // operation: false 时不注册
+	extHidden      = "x-mcp-hidden"      // parameter: 不出现在工具参数中
+	extDefault     = "x-mcp-default"     // parameter: 未传入时使用的值
+	extFixed       = "x-mcp-fixed"       // parameter: 固定值，隐藏且不可被覆盖
+)
+
+func extNode(ext *orderedmap.Map[string, *yaml.Node], key string) *yaml.Node {
+	if ext == nil {
+		return nil
+	}
+	n, _ := ext.Get(key)
+	return n
+}
+
+func extValue(ext *orderedmap.Map[string, *yaml.Node], key string) (any, bool) {
+	n := extNode(ext, key)
+	if n == nil {
+		return nil, false
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func extString(ext *orderedmap.Map[string, *yaml.Node], key string) string {
+	if v, ok := extValue(ext, key); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func extBool(ext *orderedmap.Map[string, *yaml.Node], key string, def bool) bool {
+	if v, ok := extValue(ext, key); ok {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	}
+	return def
+}
+
+// paramHidden 判断参数是否不暴露给模型
+func paramHidden(p *v3high.Parameter) bool {
+	if extBool(p.Extensions, extHidden, false) {
+		return true
+	}
+	_, fixed := extValue(p.Extensions, extFixed)
+	return fixed
+}
+
+// collectParamValues 收集参数上的 x-mcp-default 与 x-mcp-fixed
+func collectParamValues(params []*v3high.Parameter) (defaults, fixed map[string]any) {
+	defaults, fixed = map[string]any{}, map[string]any{}
+	for _, p := range params {
+		if p == nil {
+			continue
+		}
+		if v, ok := extValue(p.Extensions, extDefault); ok {
+			defaults[p.Name] = v
+		}
+		if v, ok := extValue(p.Extensions, extFixed); ok {
+			fixed[p.Name] = v
+		}
+	}
+	return defaults, fixed
+}
+
+// applyParamValues 将默认值与固定值合并到调用参数中
+func applyParamValues(args map[string]any, defaults, fixed map[string]any) map[string]any {
+	if len(defaults) == 0 && len(fixed) == 0 {
+		return args
+	}
+	out := make(map[string]any, len(args)+len(fixed))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range args {
+		out[k] = v
+	}
+	for k, v := range fixed {
+		out[k] = v
+	}
+	return out
+}
+
+// ApplyOverlay 按 OpenAPI Overlay 1.0 规范修改文档，返回修改后的 YAML
+func ApplyOverlay(spec, overlayData []byte) ([]byte, error) {
+	var ov overlay.Overlay
+	if err := yaml.Unmarshal(overlayData, &ov); err != nil {
+		return nil, fmt.Errorf("parse overlay: %w", err)
+	}
+	if err := ov.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid overlay: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(spec, &root); err != nil {
+		return nil, fmt.Errorf("parse openapi: %w", err)
+	}
+	if err := ov.ApplyTo(&root); err != nil {
+		return nil, fmt.Errorf("apply overlay: %w", err)
+	}
+	return yaml.Marshal(&root)
+}
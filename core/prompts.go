@@ -0,0 +1,278 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pb33f/libopenapi"
+	v3base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// PromptTemplate 是用户定义的提示模板。Template 中的 {{arg}} 替换为参数值，
+// {{op:toolName}} 替换为对应操作的简介
+type PromptTemplate struct {
+	Name        string           `yaml:"name" json:"name"`
+	Description string           `yaml:"description,omitempty" json:"description,omitempty"`
+	Arguments   []PromptArgument `yaml:"arguments,omitempty" json:"arguments,omitempty"`
+	Operations  []string         `yaml:"operations,omitempty" json:"operations,omitempty"` // 附在提示末尾的相关工具
+	Template    string           `yaml:"template" json:"template"`
+}
+
+// PromptArgument 的候选值来自 Values，或通过 Parameter ("tool.param") 沿用工具参数的候选值
+type PromptArgument struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool     `yaml:"required,omitempty" json:"required,omitempty"`
+	Values      []string `yaml:"values,omitempty" json:"values,omitempty"`
+	Parameter   string   `yaml:"parameter,omitempty" json:"parameter,omitempty"`
+}
+
+// LoadPromptTemplates 读取 YAML 或 JSON 格式的提示模板列表
+func LoadPromptTemplates(path string) ([]PromptTemplate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Prompts []PromptTemplate `yaml:"prompts"`
+	}
+	if err := yaml.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("parse prompts %s: %w", path, err)
+	}
+	return out.Prompts, nil
+}
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([\w.:-]+)\s*}}`)
+
+// opRef 记录工具名对应的操作
+type opRef struct {
+	path, method string
+	op           *v3high.Operation
+}
+
+func (r opRef) summary(name string) string {
+	line := fmt.Sprintf("%s (%s %s)", name, r.method, r.path)
+	if s := coalesce(r.op.Summary, extString(r.op.Extensions, extDescription)); s != "" {
+		line += ": " + s
+	}
+	return line
+}
+
+// AddPromptsFromOpenAPI 为每个标签生成介绍相关工具与主要 schema 的提示，并注册用户定义的提示模板。
+// tagPrompts 为 false 时只注册 templates
+func AddPromptsFromOpenAPI(
+	mcpServer *server.MCPServer,
+	v3Model *libopenapi.DocumentModel[v3high.Document],
+	tagPrompts bool,
+	templates []PromptTemplate,
+	opts ...Option) error {
+
+	doc := v3Model.Model
+	o := newOptions(opts)
+
+	filter, err := o.filter.compile()
+	if err != nil {
+		return err
+	}
+
+	ops := map[string]opRef{}
+	var tags []string
+	byTag := map[string][]string{}
+	err = eachOperation(doc, func(path, method string, item *v3high.PathItem, op *v3high.Operation) error {
+		name := toolName(path, method, op)
+		if ok, _ := filter.match(path, method, name, op); !ok {
+			return nil
+		}
+		ops[name] = opRef{path: path, method: method, op: op}
+		for _, t := range op.Tags {
+			if _, ok := byTag[t]; !ok {
+				tags = append(tags, t)
+			}
+			byTag[t] = append(byTag[t], name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if tagPrompts {
+		for _, tag := range tags {
+			addTagPrompt(mcpServer, doc, tag, byTag[tag], ops)
+		}
+	}
+
+	for _, t := range templates {
+		if err := addTemplatePrompt(mcpServer, t, ops); err != nil {
+			return err
+		}
+		if o.completer != nil {
+			o.completer.addPrompt(t.Name, t.Arguments)
+		}
+	}
+	return nil
+}
+
+func tagDescription(doc v3high.Document, tag string) string {
+	for _, t := range doc.Tags {
+		if t != nil && t.Name == tag {
+			return t.Description
+		}
+	}
+	return ""
+}
+
+// schemaRefName 返回引用 components 中 schema 的名称，数组取元素类型
+func schemaRefName(sp *v3base.SchemaProxy) string {
+	if sp == nil {
+		return ""
+	}
+	if sp.IsReference() {
+		ref := sp.GetReference()
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	if s := sp.Schema(); s != nil && s.Items != nil && s.Items.IsA() {
+		return schemaRefName(s.Items.A)
+	}
+	return ""
+}
+
+// operationSchemas 收集操作请求体与响应中引用的 schema 名称
+func operationSchemas(op *v3high.Operation) []string {
+	var out []string
+	add := func(sp *v3base.SchemaProxy) {
+		if n := schemaRefName(sp); n != "" && !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	if op.RequestBody != nil && op.RequestBody.Content != nil {
+		for el := op.RequestBody.Content.First(); el != nil; el = el.Next() {
+			add(el.Value().Schema)
+		}
+	}
+	if op.Responses != nil && op.Responses.Codes != nil {
+		for el := op.Responses.Codes.First(); el != nil; el = el.Next() {
+			if el.Value().Content == nil {
+				continue
+			}
+			for mt := el.Value().Content.First(); mt != nil; mt = mt.Next() {
+				add(mt.Value().Schema)
+			}
+		}
+	}
+	return out
+}
+
+func addTagPrompt(mcpServer *server.MCPServer, doc v3high.Document, tag string, names []string, ops map[string]opRef) {
+	desc := tagDescription(doc, tag)
+
+	var schemas []string
+	for _, name := range names {
+		for _, s := range operationSchemas(ops[name].op) {
+			if !slices.Contains(schemas, s) {
+				schemas = append(schemas, s)
+			}
+		}
+	}
+
+	prompt := mcp.NewPrompt(sanitizeToolName("work_with_"+tag),
+		mcp.WithPromptTitle(fmt.Sprintf("Work with the %s API", tag)),
+		mcp.WithPromptDescription(coalesce(desc, fmt.Sprintf("Tools and schemas tagged %q", tag))),
+		mcp.WithArgument("task", mcp.ArgumentDescription("What you want to accomplish")),
+	)
+	mcpServer.AddPrompt(prompt, func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "You are working with the %s API.", tag)
+		if desc != "" {
+			sb.WriteString(" " + desc)
+		}
+		sb.WriteString("\n\nAvailable tools:\n")
+		for _, name := range names {
+			sb.WriteString("- " + ops[name].summary(name) + "\n")
+		}
+		if len(schemas) > 0 {
+			sb.WriteString("\nKey schemas: " + strings.Join(schemas, ", ") + "\n")
+		}
+		if task := request.Params.Arguments["task"]; task != "" {
+			sb.WriteString("\nTask: " + task + "\n")
+		}
+		return mcp.NewGetPromptResult(prompt.Description, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(sb.String())),
+		}), nil
+	})
+}
+
+func addTemplatePrompt(mcpServer *server.MCPServer, t PromptTemplate, ops map[string]opRef) error {
+	if t.Name == "" {
+		return fmt.Errorf("prompt template without name")
+	}
+	// 启动时检查引用，避免在调用时才发现拼写错误
+	for _, name := range t.Operations {
+		if _, ok := ops[name]; !ok {
+			return fmt.Errorf("prompt %s: unknown operation %q", t.Name, name)
+		}
+	}
+	for _, a := range t.Arguments {
+		if a.Parameter == "" {
+			continue
+		}
+		name, _, ok := strings.Cut(a.Parameter, ".")
+		if !ok {
+			return fmt.Errorf("prompt %s: argument %s: parameter must be tool.param", t.Name, a.Name)
+		}
+		if _, ok := ops[name]; !ok {
+			return fmt.Errorf("prompt %s: unknown operation %q", t.Name, name)
+		}
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(t.Template, -1) {
+		if name, ok := strings.CutPrefix(m[1], "op:"); ok {
+			if _, ok := ops[name]; !ok {
+				return fmt.Errorf("prompt %s: unknown operation %q", t.Name, name)
+			}
+		}
+	}
+
+	popts := []mcp.PromptOption{mcp.WithPromptDescription(t.Description)}
+	for _, a := range t.Arguments {
+		aopts := []mcp.ArgumentOption{mcp.ArgumentDescription(a.Description)}
+		if a.Required {
+			aopts = append(aopts, mcp.RequiredArgument())
+		}
+		popts = append(popts, mcp.WithArgument(a.Name, aopts...))
+	}
+
+	mcpServer.AddPrompt(mcp.NewPrompt(t.Name, popts...), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := request.Params.Arguments
+		for _, a := range t.Arguments {
+			if a.Required && args[a.Name] == "" {
+				return nil, fmt.Errorf("missing required argument: %s", a.Name)
+			}
+		}
+
+		text := placeholderRe.ReplaceAllStringFunc(t.Template, func(s string) string {
+			key := placeholderRe.FindStringSubmatch(s)[1]
+			if name, ok := strings.CutPrefix(key, "op:"); ok {
+				return ops[name].summary(name)
+			}
+			return args[key]
+		})
+		if len(t.Operations) > 0 {
+			text = strings.TrimRight(text, "\n") + "\n\nRelevant tools:\n"
+			for _, name := range t.Operations {
+				text += "- " + ops[name].summary(name) + "\n"
+			}
+		}
+		return mcp.NewGetPromptResult(t.Description, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	})
+	return nil
+}
@@ -0,0 +1,264 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pb33f/libopenapi"
+	v3base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const (
+	SpecResourceURI      = "openapi://spec"
+	schemaResourcePrefix = "openapi://schemas/"
+	opResourcePrefix     = "openapi://operations/"
+)
+
+// SchemaResourceURI 返回 components 中 schema 对应的资源 URI
+func SchemaResourceURI(name string) string { return schemaResourcePrefix + name }
+
+// OperationResourceURI 返回工具对应操作文档的资源 URI
+func OperationResourceURI(tool string) string { return opResourcePrefix + tool }
+
+func textResource(uri, mimeType string, render func() (string, error)) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		text, err := render()
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: text},
+		}, nil
+	}
+}
+
+// AddResourcesFromOpenAPI 将文档本身、components 中的 schema 以及每个操作的说明注册为 MCP 资源，
+// 操作同样受 WithToolFilter 约束
+func AddResourcesFromOpenAPI(
+	mcpServer *server.MCPServer,
+	v3Model *libopenapi.DocumentModel[v3high.Document],
+	opts ...Option) error {
+
+	doc := v3Model.Model
+	o := newOptions(opts)
+
+	title := "OpenAPI specification"
+	if doc.Info != nil && doc.Info.Title != "" {
+		title = doc.Info.Title
+	}
+	mcpServer.AddResource(
+		mcp.NewResource(SpecResourceURI, title,
+			mcp.WithResourceDescription("The full OpenAPI document behind the tools of this server"),
+			mcp.WithMIMEType("application/yaml"),
+		),
+		textResource(SpecResourceURI, "application/yaml", func() (string, error) {
+			b, err := doc.Render()
+			return string(b), err
+		}),
+	)
+
+	if doc.Components != nil && doc.Components.Schemas != nil {
+		for el := doc.Components.Schemas.First(); el != nil; el = el.Next() {
+			name, sp := el.Key(), el.Value()
+			uri := SchemaResourceURI(name)
+			desc := "Schema " + name
+			if s := sp.Schema(); s != nil && s.Description != "" {
+				desc = s.Description
+			}
+			mcpServer.AddResource(
+				mcp.NewResource(uri, name,
+					mcp.WithResourceDescription(desc),
+					mcp.WithMIMEType("application/json"),
+				),
+				textResource(uri, "application/json", func() (string, error) {
+					return schemaJSON(sp.Schema())
+				}),
+			)
+		}
+	}
+
+	filter, err := o.filter.compile()
+	if err != nil {
+		return err
+	}
+	return eachOperation(doc, func(path, method string, item *v3high.PathItem, op *v3high.Operation) error {
+		name := toolName(path, method, op)
+		if ok, _ := filter.match(path, method, name, op); !ok {
+			return nil
+		}
+		uri := OperationResourceURI(name)
+		mcpServer.AddResource(
+			mcp.NewResource(uri, name,
+				mcp.WithResourceDescription(coalesce(op.Summary, fmt.Sprintf("%s %s", method, path))),
+				mcp.WithMIMEType("text/markdown"),
+			),
+			textResource(uri, "text/markdown", func() (string, error) {
+				return operationDoc(path, method, op, item), nil
+			}),
+		)
+		return nil
+	})
+}
+
+func schemaJSON(s *v3base.Schema) (string, error) {
+	if s == nil {
+		return "{}", nil
+	}
+	b, err := s.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, "", "  "); err != nil {
+		return string(b), nil
+	}
+	return out.String(), nil
+}
+
+func jsonBlock(sb *strings.Builder, v any) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return
+	}
+	sb.WriteString("```json\n")
+	sb.Write(b)
+	sb.WriteString("\n```\n")
+}
+
+func schemaBlock(sb *strings.Builder, s *v3base.Schema) {
+	if text, err := schemaJSON(s); err == nil {
+		sb.WriteString("```json\n")
+		sb.WriteString(text)
+		sb.WriteString("\n```\n")
+	}
+}
+
+func schemaTypeLabel(p *v3high.Parameter) string {
+	if p.Schema == nil {
+		return ""
+	}
+	s := p.Schema.Schema()
+	if s == nil {
+		return ""
+	}
+	t := firstType(s.Type)
+	if s.Format != "" {
+		t += " (" + s.Format + ")"
+	}
+	if len(s.Enum) > 0 {
+		t += ": " + strings.Join(yamlNodesToStrs(s.Enum), " \\| ")
+	}
+	return t
+}
+
+func tableCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\n", " "), "|", "\\|")
+}
+
+// operationDoc 生成单个操作的 Markdown 文档：参数、请求体、响应与示例
+func operationDoc(path, method string, op *v3high.Operation, item *v3high.PathItem) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n`%s %s`\n\n", toolName(path, method, op), method, path)
+	if op.Summary != "" {
+		sb.WriteString(op.Summary + "\n\n")
+	}
+	if op.Description != "" {
+		sb.WriteString(op.Description + "\n\n")
+	}
+	if len(op.Tags) > 0 {
+		fmt.Fprintf(&sb, "Tags: %s\n\n", strings.Join(op.Tags, ", "))
+	}
+	if boolVal(op.Deprecated) {
+		sb.WriteString("**Deprecated**\n\n")
+	}
+
+	params := mergeParameters(item.Parameters, op.Parameters)
+	if len(params) > 0 {
+		sb.WriteString("## Parameters\n\n| Name | In | Type | Required | Description |\n|---|---|---|---|---|\n")
+		for _, p := range params {
+			if p == nil || paramHidden(p) {
+				continue
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %t | %s |\n",
+				p.Name, p.In, schemaTypeLabel(p), boolVal(p.Required), tableCell(p.Description))
+		}
+		sb.WriteString("\n")
+	}
+
+	if rb := op.RequestBody; rb != nil && rb.Content != nil {
+		sb.WriteString("## Request Body\n\n")
+		if rb.Description != "" {
+			sb.WriteString(rb.Description + "\n\n")
+		}
+		for el := rb.Content.First(); el != nil; el = el.Next() {
+			fmt.Fprintf(&sb, "Content-Type: `%s`", el.Key())
+			if boolVal(rb.Required) {
+				sb.WriteString(" (required)")
+			}
+			sb.WriteString("\n\n")
+			mt := el.Value()
+			if mt.Schema != nil {
+				schemaBlock(&sb, mt.Schema.Schema())
+			}
+			if v, ok := nodeValue(mt.Example); ok {
+				sb.WriteString("\nExample:\n\n")
+				jsonBlock(&sb, v)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if op.Responses != nil {
+		sb.WriteString("## Responses\n\n")
+		writeResponse := func(code string, r *v3high.Response) {
+			fmt.Fprintf(&sb, "### %s\n\n", code)
+			if r.Description != "" {
+				sb.WriteString(r.Description + "\n\n")
+			}
+			if mt := pickMediaType(r.Content); mt != nil {
+				if mt.Schema != nil {
+					schemaBlock(&sb, mt.Schema.Schema())
+				}
+				if v, ok := nodeValue(mt.Example); ok {
+					sb.WriteString("\nExample:\n\n")
+					jsonBlock(&sb, v)
+				}
+				sb.WriteString("\n")
+			}
+			if r.Links != nil && r.Links.Len() > 0 {
+				sb.WriteString("Links:\n\n")
+				for el := r.Links.First(); el != nil; el = el.Next() {
+					l := el.Value()
+					fmt.Fprintf(&sb, "- %s: %s", el.Key(), coalesce(l.OperationId, l.OperationRef))
+					if l.Parameters != nil {
+						var args []string
+						for p := l.Parameters.First(); p != nil; p = p.Next() {
+							args = append(args, fmt.Sprintf("`%s` = `%s`", p.Key(), p.Value()))
+						}
+						sb.WriteString(" (" + strings.Join(args, ", ") + ")")
+					}
+					if l.Description != "" {
+						sb.WriteString(" - " + l.Description)
+					}
+					sb.WriteString("\n")
+				}
+				sb.WriteString("\n")
+			}
+		}
+		if op.Responses.Codes != nil {
+			for el := op.Responses.Codes.First(); el != nil; el = el.Next() {
+				writeResponse(el.Key(), el.Value())
+			}
+		}
+		if op.Responses.Default != nil {
+			writeResponse("default", op.Responses.Default)
+		}
+	}
+	return sb.String()
+}
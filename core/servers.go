@@ -0,0 +1,143 @@
+package core
+
+import (
+	"fmt"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+var serverVarRe = pathVarRe
+
+// serverName 返回用于选择 server 的名称：x-name、description 或下标
+func serverName(s *v3high.Server, idx int) string {
+	if s.Extensions != nil {
+		if n, ok := s.Extensions.Get("x-name"); ok && n != nil && n.Value != "" {
+			return n.Value
+		}
+	}
+	if s.Description != "" {
+		return s.Description
+	}
+	return strconv.Itoa(idx)
+}
+
+// selectServer 按 description、x-name 扩展或下标选择 server，name 为空时返回第一个，
+// 未命中时返回列出可选名称的错误
+func selectServer(servers []*v3high.Server, name string) (*v3high.Server, error) {
+	if len(servers) == 0 {
+		return nil, nil
+	}
+	if name == "" {
+		return servers[0], nil
+	}
+	if idx, err := strconv.Atoi(name); err == nil && idx >= 0 && idx < len(servers) {
+		return servers[idx], nil
+	}
+	var names []string
+	for i, s := range servers {
+		if s == nil {
+			continue
+		}
+		if strings.EqualFold(s.Description, name) {
+			return s, nil
+		}
+		if s.Extensions != nil {
+			if n, ok := s.Extensions.Get("x-name"); ok && n != nil && strings.EqualFold(n.Value, name) {
+				return s, nil
+			}
+		}
+		names = append(names, strconv.Quote(serverName(s, i)))
+	}
+	return nil, fmt.Errorf("unknown server %q, valid names: %s", name, strings.Join(names, ", "))
+}
+
+// expandServerURL 使用配置值或默认值替换 {variable}
+func expandServerURL(s *v3high.Server, vars map[string]string) (string, error) {
+	var err error
+	out := serverVarRe.ReplaceAllStringFunc(s.URL, func(m string) string {
+		name := m[1 : len(m)-1]
+		var def *v3high.ServerVariable
+		if s.Variables != nil {
+			def, _ = s.Variables.Get(name)
+		}
+		if v, ok := vars[name]; ok {
+			if def != nil && len(def.Enum) > 0 && !slices.Contains(def.Enum, v) {
+				err = fmt.Errorf("server variable %s=%q not in %v", name, v, def.Enum)
+			}
+			return v
+		}
+		if def != nil && def.Default != "" {
+			return def.Default
+		}
+		err = fmt.Errorf("server variable %s has no value for %s", name, s.URL)
+		return m
+	})
+	return out, err
+}
+
+// resolveServerURL 将相对 server URL 基于 OpenAPI 文档的来源地址解析为绝对地址
+func resolveServerURL(raw, specURL string) string {
+	u, err := neturl.Parse(raw)
+	if err != nil || u.IsAbs() || specURL == "" {
+		return raw
+	}
+	base, err := neturl.Parse(specURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return raw
+	}
+	return base.ResolveReference(u).String()
+}
+
+func serverBaseURL(servers []*v3high.Server, name string, o *options) (string, error) {
+	s, err := selectServer(servers, name)
+	if s == nil || err != nil {
+		return "", err
+	}
+	u, err := expandServerURL(s, o.serverVars)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(resolveServerURL(u, o.specURL), "/"), nil
+}
+
+// operationBaseURL 依次使用 operation、path、document 级别的 servers。
+// 选择的名称属于文档级 servers 而不在 operation 或 path 级别中时，使用后者的第一个
+func operationBaseURL(doc v3high.Document, item *v3high.PathItem, op *v3high.Operation, o *options) (string, error) {
+	servers := doc.Servers
+	switch {
+	case len(op.Servers) > 0:
+		servers = op.Servers
+	case len(item.Servers) > 0:
+		servers = item.Servers
+	default:
+		return serverBaseURL(servers, o.serverName, o)
+	}
+	name := o.serverName
+	if _, err := selectServer(servers, name); err != nil {
+		if s, derr := selectServer(doc.Servers, name); s != nil && derr == nil {
+			name = ""
+		}
+	}
+	return serverBaseURL(servers, name, o)
+}
+
+// ServerURLs 展开文档级 servers 中的全部地址，可用于构建 UpstreamPool
+func ServerURLs(doc v3high.Document, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+	out := make([]string, 0, len(doc.Servers))
+	for _, s := range doc.Servers {
+		if s == nil {
+			continue
+		}
+		u, err := expandServerURL(s, o.serverVars)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, strings.TrimRight(resolveServerURL(u, o.specURL), "/"))
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+const serversSpec = `
+openapi: 3.0.3
+info: {title: Pets, version: 1.0.0}
+servers:
+  - {url: https://api.example.com, description: production}
+  - {url: https://staging.example.com, x-name: staging}
+  - {url: http://localhost:8080}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200': {description: ok}
+  /uploads:
+    servers:
+      - {url: https://upload.example.com}
+    post:
+      operationId: upload
+      responses:
+        '200': {description: ok}
+`
+
+func TestOperationBaseURL(t *testing.T) {
+	doc := loadTestDoc(t, serversSpec).Model
+	pets, _ := doc.Paths.PathItems.Get("/pets")
+	uploads, _ := doc.Paths.PathItems.Get("/uploads")
+
+	tests := []struct {
+		name       string
+		server     string
+		want       string
+		wantUpload string
+		wantErr    string
+	}{
+		{name: "default", want: "https://api.example.com", wantUpload: "https://upload.example.com"},
+		{name: "description", server: "Production", want: "https://api.example.com", wantUpload: "https://upload.example.com"},
+		{name: "x-name", server: "staging", want: "https://staging.example.com", wantUpload: "https://upload.example.com"},
+		{name: "index", server: "2", want: "http://localhost:8080", wantUpload: "https://upload.example.com"},
+		{name: "unknown", server: "prod", wantErr: `unknown server "prod", valid names: "production", "staging", "2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions([]Option{WithServer(tt.server)})
+			got, err := operationBaseURL(doc, pets, pets.Get, o)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if _, err := operationBaseURL(doc, uploads, uploads.Post, o); err == nil {
+					t.Error("path-level servers accepted an unknown server name")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("base URL = %q, want %q", got, tt.want)
+			}
+			if got, err := operationBaseURL(doc, uploads, uploads.Post, o); err != nil || got != tt.wantUpload {
+				t.Errorf("path-level base URL = %q, %v, want %q", got, err, tt.wantUpload)
+			}
+		})
+	}
+}
@@ -21,6 +21,18 @@ func (s *State) GetSetting(k string) (any, bool) {
 	v, ok := s.Settings[k]
 	return v, ok
 }
+
+// AllSettings 返回全部设置的副本
+func (s *State) AllSettings() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]any, len(s.Settings))
+	for k, v := range s.Settings {
+		out[k] = v
+	}
+	return out
+}
+
 func (s *State) SetSetting(k string, v any) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -37,11 +49,16 @@ type Manager struct {
 	once     sync.Once
 )
 
+// NewManager 创建独立的会话管理器，嵌入其它服务时代替全局的 Instance
+func NewManager() *Manager {
+	return &Manager{
+		sessions: make(map[string]*State),
+	}
+}
+
 func Instance() *Manager {
 	once.Do(func() {
-		instance = &Manager{
-			sessions: make(map[string]*State),
-		}
+		instance = NewManager()
 	})
 	return instance
 }
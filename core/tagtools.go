@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// untaggedGroup 收纳没有标签的操作
+const untaggedGroup = "other"
+
+type tagGroup struct {
+	tag   string
+	tools []operationTool
+}
+
+// groupByTag 按操作的第一个标签分组，保持文档顺序
+func groupByTag(tools []operationTool) []*tagGroup {
+	var groups []*tagGroup
+	byTag := map[string]*tagGroup{}
+	for _, t := range tools {
+		tag := untaggedGroup
+		if len(t.op.Tags) > 0 {
+			tag = t.op.Tags[0]
+		}
+		g, ok := byTag[tag]
+		if !ok {
+			g = &tagGroup{tag: tag}
+			byTag[tag] = g
+			groups = append(groups, g)
+		}
+		g.tools = append(g.tools, t)
+	}
+	return groups
+}
+
+// mergeAnnotations 合并组内工具的注解：全部只读才只读，任一破坏性即破坏性
+func mergeAnnotations(title string, tools []operationTool) mcp.ToolAnnotation {
+	readOnly, idempotent := true, true
+	destructive, openWorld := false, false
+	for _, t := range tools {
+		a := t.tool.Annotations
+		readOnly = readOnly && boolVal(a.ReadOnlyHint)
+		idempotent = idempotent && boolVal(a.IdempotentHint)
+		destructive = destructive || boolVal(a.DestructiveHint)
+		openWorld = openWorld || a.OpenWorldHint == nil || *a.OpenWorldHint
+	}
+	return mcp.ToolAnnotation{
+		Title:           title,
+		ReadOnlyHint:    mcp.ToBoolPtr(readOnly),
+		DestructiveHint: mcp.ToBoolPtr(destructive),
+		IdempotentHint:  mcp.ToBoolPtr(idempotent),
+		OpenWorldHint:   mcp.ToBoolPtr(openWorld),
+	}
+}
+
+// tagToolSchema 生成以 operation 为判别字段的 oneOf 输入 schema，每个分支保留操作自身的参数 schema
+func tagToolSchema(tools []operationTool) (json.RawMessage, error) {
+	names := make([]string, 0, len(tools))
+	branches := make([]any, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.name)
+		args, err := json.Marshal(t.tool.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.name, err)
+		}
+		branch := map[string]any{
+			"properties": map[string]any{
+				"operation": map[string]any{"const": t.name},
+				"arguments": json.RawMessage(args),
+			},
+			"required": []string{"operation"},
+		}
+		if len(t.tool.InputSchema.Required) > 0 {
+			branch["required"] = []string{"operation", "arguments"}
+		}
+		if desc := coalesce(t.op.Summary, t.tool.Description); desc != "" {
+			branch["description"] = desc
+		}
+		branches = append(branches, branch)
+	}
+	return json.Marshal(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"operation": map[string]any{
+				"type":        "string",
+				"enum":        names,
+				"description": "Operation to invoke",
+			},
+			"arguments": map[string]any{
+				"type":        "object",
+				"description": "Arguments of the selected operation",
+			},
+		},
+		"required": []string{"operation"},
+		"oneOf":    branches,
+	})
+}
+
+// addTagTools 为每个标签注册一个工具，通过 operation 字段选择具体操作
+func addTagTools(mcpServer ToolRegistry, doc v3high.Document, tools []operationTool) error {
+	for _, g := range groupByTag(tools) {
+		schema, err := tagToolSchema(g.tools)
+		if err != nil {
+			return fmt.Errorf("tag %s: %w", g.tag, err)
+		}
+
+		var sb strings.Builder
+		if desc := tagDescription(doc, g.tag); desc != "" {
+			sb.WriteString(desc + "\n\n")
+		}
+		sb.WriteString("Select one operation:\n")
+		for _, t := range g.tools {
+			fmt.Fprintf(&sb, "- %s: %s %s", t.name, t.method, t.path)
+			if t.op.Summary != "" {
+				sb.WriteString(" - " + t.op.Summary)
+			}
+			sb.WriteString("\n")
+		}
+
+		tool := mcp.NewToolWithRawSchema(sanitizeToolName(g.tag), sb.String(), schema)
+		tool.Annotations = mergeAnnotations(fmt.Sprintf("%s API", g.tag), g.tools)
+
+		byName := make(map[string]operationTool, len(g.tools))
+		for _, t := range g.tools {
+			byName[t.name] = t
+		}
+		mcpServer.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := request.RequireString("operation")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			t, ok := byName[name]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown operation %q", name)), nil
+			}
+			args, _ := request.GetArguments()["arguments"].(map[string]any)
+			return t.invoke(ctx, args)
+		})
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// templateScheme 是资源模板 URI 的前缀，例如 /users/{id} 对应 api://users/{id}
+const templateScheme = "api://"
+
+// resourceTemplateURI 由路径模板与查询参数生成 RFC 6570 URI 模板，
+// 查询参数使用 {?a,b} 形式，可以省略
+func resourceTemplateURI(path string, params []*v3high.Parameter) string {
+	uri := templateScheme + strings.TrimPrefix(path, "/")
+	var query []string
+	for _, p := range params {
+		if p != nil && p.In == "query" && !paramHidden(p) {
+			query = append(query, p.Name)
+		}
+	}
+	if len(query) > 0 {
+		uri += "{?" + strings.Join(query, ",") + "}"
+	}
+	return uri
+}
+
+// declaredMediaType 返回操作成功响应声明的内容类型
+func declaredMediaType(op *v3high.Operation) string {
+	_, resp := pickResponse(op, "")
+	if resp == nil || resp.Content == nil {
+		return ""
+	}
+	mt := pickMediaType(resp.Content)
+	for el := resp.Content.First(); el != nil; el = el.Next() {
+		if el.Value() == mt {
+			return el.Key()
+		}
+	}
+	return ""
+}
+
+// addResourceTemplate 将带路径参数的 GET 操作注册为资源模板，读取时复用工具的请求逻辑
+func addResourceTemplate(mcpServer ResourceTemplateRegistry, name, path, method string,
+	op *v3high.Operation, item *v3high.PathItem,
+	baseURL string, extraHeaders map[string]string, o *options, opts []Option) {
+
+	if method != http.MethodGet || len(parsePathTmpl(path)) == 0 {
+		return
+	}
+	if o.confirm.needConfirm(method, op, o.destructiveMethods) {
+		// 读取资源时无法完成确认流程
+		o.logger.Printf("skip resource template %s: operation requires confirmation", name)
+		return
+	}
+
+	params := mergeParameters(item.Parameters, op.Parameters)
+	uri := resourceTemplateURI(path, params)
+	tmpl, err := uritemplate.New(uri)
+	if err != nil {
+		o.logger.Printf("skip resource template %s: %v", name, err)
+		return
+	}
+
+	tmplOpts := []mcp.ResourceTemplateOption{
+		mcp.WithTemplateDescription(coalesce(extString(op.Extensions, extDescription), op.Description, op.Summary,
+			fmt.Sprintf("%s %s", method, path))),
+	}
+	if mt := declaredMediaType(op); mt != "" {
+		tmplOpts = append(tmplOpts, mcp.WithTemplateMIMEType(mt))
+	}
+	if op.Summary != "" {
+		tmplOpts = append(tmplOpts, mcp.WithTemplateTitle(op.Summary))
+	}
+
+	paramIn, _ := collectParamLocation(item, op)
+	if o.completer != nil {
+		o.completer.addTemplate(tmpl.Raw(), name)
+	}
+	o.logger.Printf("include resource template %s (%s)", name, tmpl.Raw())
+	mcpServer.AddResourceTemplate(
+		mcp.NewResourceTemplate(tmpl.Raw(), name, tmplOpts...),
+		NewResourceHandlerFromOp(baseURL, path, method, paramIn, extraHeaders, opts...),
+	)
+}
+
+// templateArgs 将 URI 模板匹配出的变量转换为调用参数
+func templateArgs(vars map[string]any) map[string]any {
+	out := make(map[string]any, len(vars))
+	for k, v := range vars {
+		switch vv := v.(type) {
+		case []string:
+			if len(vv) > 0 {
+				out[k] = strings.Join(vv, ",")
+			}
+		case string:
+			out[k] = vv
+		}
+	}
+	return out
+}
+
+func isTextMediaType(mt string) bool {
+	if strings.HasPrefix(mt, "text/") {
+		return true
+	}
+	for _, s := range []string{"json", "xml", "yaml", "javascript", "x-www-form-urlencoded"} {
+		if strings.Contains(mt, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceContents 按上游返回的内容类型生成文本或二进制资源内容
+func resourceContents(uri, contentType string, body []byte) mcp.ResourceContents {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = contentType
+	}
+	if isTextMediaType(mt) {
+		return mcp.TextResourceContents{URI: uri, MIMEType: mt, Text: string(body)}
+	}
+	return mcp.BlobResourceContents{URI: uri, MIMEType: mt, Blob: base64.StdEncoding.EncodeToString(body)}
+}
+
+// resultContents 将没有访问上游的调用结果 (dry-run、mock 或确认被拒绝) 转换为资源内容
+func resultContents(uri string, res *mcp.CallToolResult) ([]mcp.ResourceContents, error) {
+	if res.IsError {
+		return nil, errors.New(resultText(res))
+	}
+	body := []byte(resultText(res))
+	if res.StructuredContent != nil {
+		var err error
+		if body, err = json.Marshal(res.StructuredContent); err != nil {
+			return nil, err
+		}
+	}
+	return []mcp.ResourceContents{resourceContents(uri, "application/json", body)}, nil
+}
+
+// NewResourceHandlerFromOp 返回读取资源模板时调用上游的处理函数，
+// 上游错误状态码作为读取失败返回
+func NewResourceHandlerFromOp(
+	baseURL, pathTmpl, method string,
+	paramIn map[string]string,
+	extraHeaders map[string]string,
+	opts ...Option,
+) server.ResourceTemplateHandlerFunc {
+
+	o := newOptions(opts)
+	c := newOpCaller(baseURL, pathTmpl, method, paramIn, false, extraHeaders, o)
+
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		uri := request.Params.URI
+		raw := applyParamValues(templateArgs(request.Params.Arguments), o.paramDefaults, o.paramFixed)
+		if missing := missingArgs(raw, o.requiredArgs); len(missing) > 0 {
+			names := make([]string, 0, len(missing))
+			for _, m := range missing {
+				names = append(names, m.name)
+			}
+			return nil, fmt.Errorf("missing required arguments: %s", strings.Join(names, ", "))
+		}
+
+		p, err := c.prepare(raw)
+		if err != nil {
+			return nil, err
+		}
+		// dry-run 与 mock 与工具调用一致，返回请求描述或示例响应而不访问上游
+		resp, res, err := c.call(ctx, p, nil)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			return resultContents(uri, res)
+		}
+		defer resp.Body.Close()
+
+		rb, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read body: %w", err)
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, fmt.Errorf("upstream returned %s: %s", resp.Status, rb)
+		}
+		return []mcp.ResourceContents{resourceContents(uri, resp.Header.Get("Content-Type"), rb)}, nil
+	}
+}
@@ -0,0 +1,335 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// extTransform 是 operation 上的 x-mcp-transform 扩展，内容与 Transform 相同 (省略 operation)
+const extTransform = "x-mcp-transform"
+
+// Transform 定义操作的请求与响应转换。表达式使用 expr 语言，只能访问下列变量与内置函数，
+// 不能读写文件、环境变量或发起网络请求。
+//
+// 请求表达式可以使用 tool、method、url、headers、body、rawBody、args 与 session (会话设置)；
+// 响应表达式可以使用 tool、args、status、headers、body、rawBody 与 session。
+// body 为 JSON 解码后的值，无法解码时为字符串
+type Transform struct {
+	Operation string           `yaml:"operation,omitempty" json:"operation,omitempty"` // 工具名
+	Request   RequestTransform `yaml:"request,omitempty" json:"request,omitempty"`
+	Response  string           `yaml:"response,omitempty" json:"response,omitempty"` // 结果替换响应体，字符串原样返回，其它值编码为 JSON
+}
+
+// RequestTransform 依次对 body、query 与 headers 求值，后面的表达式看到的是已转换的请求，
+// 因此签名头可以覆盖最终的 URL 与请求体
+type RequestTransform struct {
+	Body    string            `yaml:"body,omitempty" json:"body,omitempty"`       // 结果编码为 JSON 作为新的请求体
+	Query   map[string]string `yaml:"query,omitempty" json:"query,omitempty"`     // 查询参数 -> 表达式，结果为 nil 时删除
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"` // 请求头 -> 表达式，结果为 nil 时删除
+}
+
+// LoadTransforms 读取 YAML 或 JSON 格式的转换列表
+func LoadTransforms(path string) ([]Transform, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Transforms []Transform `yaml:"transforms"`
+	}
+	if err := yaml.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("parse transforms %s: %w", path, err)
+	}
+	return out.Transforms, nil
+}
+
+// requestEnv 与 responseEnv 是表达式可访问的变量，同时用于编译期检查变量名
+type requestEnv struct {
+	Tool    string         `expr:"tool"`
+	Method  string         `expr:"method"`
+	URL     string         `expr:"url"`
+	Headers map[string]any `expr:"headers"`
+	Body    any            `expr:"body"`
+	RawBody string         `expr:"rawBody"`
+	Args    map[string]any `expr:"args"`
+	Session map[string]any `expr:"session"`
+}
+
+type responseEnv struct {
+	Tool    string         `expr:"tool"`
+	Args    map[string]any `expr:"args"`
+	Status  int            `expr:"status"`
+	Headers map[string]any `expr:"headers"`
+	Body    any            `expr:"body"`
+	RawBody string         `expr:"rawBody"`
+	Session map[string]any `expr:"session"`
+}
+
+// transformFunctions 是表达式中可用的额外函数，其余为 expr 内置函数 (toJSON、toBase64、now 等)
+var transformFunctions = []expr.Option{
+	expr.Function("sha256", func(params ...any) (any, error) {
+		if len(params) < 1 || len(params) > 2 {
+			return nil, fmt.Errorf("sha256(data[, encoding]) takes 1 or 2 arguments")
+		}
+		sum := sha256.Sum256([]byte(fmt.Sprint(params[0])))
+		return encodeDigest(sum[:], params[1:])
+	}),
+	expr.Function("hmacSha256", func(params ...any) (any, error) {
+		if len(params) < 2 || len(params) > 3 {
+			return nil, fmt.Errorf("hmacSha256(key, data[, encoding]) takes 2 or 3 arguments")
+		}
+		mac := hmac.New(sha256.New, []byte(fmt.Sprint(params[0])))
+		mac.Write([]byte(fmt.Sprint(params[1])))
+		return encodeDigest(mac.Sum(nil), params[2:])
+	}),
+	expr.Function("uuid", func(params ...any) (any, error) {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	}),
+	expr.Function("pick", func(params ...any) (any, error) {
+		return selectKeys(params, true)
+	}),
+	expr.Function("omit", func(params ...any) (any, error) {
+		return selectKeys(params, false)
+	}),
+}
+
+// encodeDigest 按 hex (默认) 或 base64 编码摘要
+func encodeDigest(sum []byte, enc []any) (any, error) {
+	if len(enc) == 0 || enc[0] == "hex" {
+		return hex.EncodeToString(sum), nil
+	}
+	if enc[0] == "base64" {
+		return base64.StdEncoding.EncodeToString(sum), nil
+	}
+	return nil, fmt.Errorf("unknown encoding %v, use hex or base64", enc[0])
+}
+
+// selectKeys 实现 pick(obj, keys...) 与 omit(obj, keys...)
+func selectKeys(params []any, keep bool) (any, error) {
+	if len(params) == 0 {
+		return nil, fmt.Errorf("missing object argument")
+	}
+	obj, ok := params[0].(map[string]any)
+	if !ok {
+		return params[0], nil
+	}
+	var keys []string
+	for _, k := range params[1:] {
+		keys = append(keys, fmt.Sprint(k))
+	}
+	out := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if slices.Contains(keys, k) == keep {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// compiledTransform 是启动时编译好的转换，表达式错误在注册工具时报告
+type compiledTransform struct {
+	src      Transform
+	body     *vm.Program
+	query    map[string]*vm.Program
+	headers  map[string]*vm.Program
+	response *vm.Program
+}
+
+func compileTransform(t Transform) (*compiledTransform, error) {
+	compile := func(where, code string, env any) (*vm.Program, error) {
+		if code == "" {
+			return nil, nil
+		}
+		p, err := expr.Compile(code, append([]expr.Option{expr.Env(env)}, transformFunctions...)...)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %s: %w", t.Operation, where, err)
+		}
+		return p, nil
+	}
+	ct := &compiledTransform{src: t, query: map[string]*vm.Program{}, headers: map[string]*vm.Program{}}
+	var err error
+	if ct.body, err = compile("request.body", t.Request.Body, requestEnv{}); err != nil {
+		return nil, err
+	}
+	for k, code := range t.Request.Query {
+		if ct.query[k], err = compile("request.query."+k, code, requestEnv{}); err != nil {
+			return nil, err
+		}
+	}
+	for k, code := range t.Request.Headers {
+		if ct.headers[k], err = compile("request.headers."+k, code, requestEnv{}); err != nil {
+			return nil, err
+		}
+	}
+	if ct.response, err = compile("response", t.Response, responseEnv{}); err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+// collectTransforms 合并文档中的 x-mcp-transform 与配置的转换，配置优先
+func collectTransforms(name string, ext *yaml.Node, configured map[string]Transform) (*compiledTransform, error) {
+	t, ok := configured[name]
+	if !ok && ext != nil {
+		if err := ext.Decode(&t); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", name, extTransform, err)
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, nil
+	}
+	t.Operation = name
+	return compileTransform(t)
+}
+
+// headerMap 将请求头转换为表达式中的 map，多个值只取第一个
+func headerMap(h http.Header) map[string]any {
+	out := make(map[string]any, len(h))
+	for k := range h {
+		out[k] = h.Get(k)
+	}
+	return out
+}
+
+// sessionSettings 返回当前会话的设置，没有会话时为空
+func (o *options) sessionSettings(ctx context.Context) map[string]any {
+	if o.sessions != nil {
+		if cs := server.ClientSessionFromContext(ctx); cs != nil {
+			if st, ok := o.sessions.GetSession(cs.SessionID()); ok {
+				return st.AllSettings()
+			}
+		}
+	}
+	return map[string]any{}
+}
+
+// applyRequest 转换已构造好的请求，返回新的请求体
+func (ct *compiledTransform) applyRequest(ctx context.Context, o *options, req *http.Request, args map[string]any, body []byte) ([]byte, error) {
+	env := &requestEnv{
+		Tool:    o.toolName,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: headerMap(req.Header),
+		Body:    decodeBody(body),
+		RawBody: string(body),
+		Args:    args,
+		Session: o.sessionSettings(ctx),
+	}
+
+	if ct.body != nil {
+		v, err := expr.Run(ct.body, env)
+		if err != nil {
+			return nil, fmt.Errorf("transform request body: %w", err)
+		}
+		if body, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("transform request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		req.Header.Set("Content-Type", "application/json")
+		env.Body, env.RawBody = v, string(body)
+	}
+
+	if len(ct.query) > 0 {
+		q := req.URL.Query()
+		for _, k := range sortedProgramKeys(ct.query) {
+			v, err := expr.Run(ct.query[k], env)
+			if err != nil {
+				return nil, fmt.Errorf("transform query %s: %w", k, err)
+			}
+			if v == nil {
+				q.Del(k)
+			} else {
+				q.Set(k, fmt.Sprint(v))
+			}
+		}
+		req.URL.RawQuery = q.Encode()
+		env.URL = req.URL.String()
+	}
+
+	for _, k := range sortedProgramKeys(ct.headers) {
+		v, err := expr.Run(ct.headers[k], env)
+		if err != nil {
+			return nil, fmt.Errorf("transform header %s: %w", k, err)
+		}
+		if v == nil {
+			req.Header.Del(k)
+		} else {
+			req.Header.Set(k, fmt.Sprint(v))
+		}
+	}
+	return body, nil
+}
+
+// applyResponse 用响应表达式的结果替换响应体，状态码与其余响应头保持不变
+func (ct *compiledTransform) applyResponse(ctx context.Context, o *options, resp *http.Response, args map[string]any) error {
+	if ct.response == nil {
+		return nil
+	}
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	v, err := expr.Run(ct.response, &responseEnv{
+		Tool:    o.toolName,
+		Args:    args,
+		Status:  resp.StatusCode,
+		Headers: headerMap(resp.Header),
+		Body:    decodeBody(raw),
+		RawBody: string(raw),
+		Session: o.sessionSettings(ctx),
+	})
+	if err != nil {
+		return fmt.Errorf("transform response: %w", err)
+	}
+
+	var out []byte
+	if s, ok := v.(string); ok {
+		out = []byte(s)
+		resp.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		if out, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("transform response: %w", err)
+		}
+		resp.Header.Set("Content-Type", "application/json")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	resp.ContentLength = int64(len(out))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+	return nil
+}
+
+func sortedProgramKeys(m map[string]*vm.Program) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
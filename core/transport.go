@@ -0,0 +1,85 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+)
+
+// TransportConfig 描述访问上游 API 时使用的连接参数，
+// 由所有会话 Client 以及 OpenAPI 文档加载共享
+type TransportConfig struct {
+	ProxyURL           string `yaml:"proxy"`              // http://、https:// 或 socks5://，为空时沿用 HTTP_PROXY 等环境变量
+	CAFile             string `yaml:"caFile"`             // 额外信任的根证书 (PEM)
+	CertFile           string `yaml:"certFile"`           // mTLS 客户端证书 (PEM)
+	KeyFile            string `yaml:"keyFile"`            // mTLS 客户端私钥 (PEM)
+	MinTLSVersion      string `yaml:"minTLSVersion"`      // 1.0 / 1.1 / 1.2 / 1.3
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"` // 仅用于开发环境
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func NewHTTPTransport(cfg TransportConfig) (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxy, err := neturl.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy url: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+		}
+		tr.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	if cfg.MinTLSVersion != "" {
+		v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(cfg.MinTLSVersion), "tls")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls version %q", cfg.MinTLSVersion)
+		}
+		tlsCfg.MinVersion = v
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	tr.TLSClientConfig = tlsCfg
+	return tr, nil
+}
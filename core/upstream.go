@@ -0,0 +1,243 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StrategyRoundRobin = "round-robin"
+	StrategyPriority   = "priority"
+)
+
+type UpstreamPoolConfig struct {
+	Strategy       string        `yaml:"strategy"`       // round-robin / priority，默认 priority
+	HealthPath     string        `yaml:"healthPath"`     // 主动健康检查路径，为空时只做被动检查
+	HealthInterval time.Duration `yaml:"healthInterval"` // 默认 30s
+}
+
+type upstreamTarget struct {
+	url       string
+	healthy   atomic.Bool
+	requests  expvar.Int
+	failures  expvar.Int
+	failovers expvar.Int
+	up        expvar.Int
+}
+
+func (t *upstreamTarget) setHealthy(ok bool, logger *log.Logger, reason string) {
+	if t.healthy.Swap(ok) != ok {
+		if ok {
+			logger.Printf("upstream %s is healthy again", t.url)
+		} else {
+			logger.Printf("upstream %s marked unhealthy: %s", t.url, reason)
+		}
+	}
+	if ok {
+		t.up.Set(1)
+	} else {
+		t.up.Set(0)
+	}
+}
+
+// UpstreamPool 在多个上游地址之间选择，并对幂等请求自动故障转移
+type UpstreamPool struct {
+	targets []*upstreamTarget
+	cfg     UpstreamPoolConfig
+	next    atomic.Uint64
+	client  *http.Client
+	logger  *log.Logger
+	metrics *expvar.Map
+}
+
+func NewUpstreamPool(urls []string, cfg UpstreamPoolConfig, cli *http.Client, logger *log.Logger) (*UpstreamPool, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("upstream pool requires at least one url")
+	}
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = StrategyPriority
+	case StrategyPriority, StrategyRoundRobin:
+	default:
+		return nil, fmt.Errorf("unknown upstream strategy %q", cfg.Strategy)
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = 30 * time.Second
+	}
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+
+	p := &UpstreamPool{cfg: cfg, client: cli, logger: logger, metrics: new(expvar.Map).Init()}
+	for _, u := range urls {
+		t := &upstreamTarget{url: strings.TrimRight(u, "/")}
+		t.healthy.Store(true)
+		t.up.Set(1)
+		m := new(expvar.Map).Init()
+		m.Set("healthy", &t.up)
+		m.Set("requests", &t.requests)
+		m.Set("failures", &t.failures)
+		m.Set("failovers", &t.failovers)
+		p.metrics.Set(t.url, m)
+		p.targets = append(p.targets, t)
+	}
+	return p, nil
+}
+
+// Metrics 返回各上游的计数 (healthy、requests、failures、failovers)，键为上游地址。
+// 池本身不发布到 expvar，需要时由调用方 Publish
+func (p *UpstreamPool) Metrics() *expvar.Map {
+	return p.metrics
+}
+
+// URLs 返回池中的全部上游地址
+func (p *UpstreamPool) URLs() []string {
+	out := make([]string, 0, len(p.targets))
+	for _, t := range p.targets {
+		out = append(out, t.url)
+	}
+	return out
+}
+
+// Run 周期性执行主动健康检查，直到 ctx 结束
+func (p *UpstreamPool) Run(ctx context.Context) {
+	if p.cfg.HealthPath == "" {
+		return
+	}
+	ticker := time.NewTicker(p.cfg.HealthInterval)
+	defer ticker.Stop()
+	for {
+		p.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *UpstreamPool) checkAll(ctx context.Context) {
+	for _, t := range p.targets {
+		ok, reason := p.check(ctx, t)
+		t.setHealthy(ok, p.logger, reason)
+	}
+}
+
+func (p *UpstreamPool) check(ctx context.Context, t *upstreamTarget) (bool, string) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url+p.cfg.HealthPath, nil)
+	if err != nil {
+		return false, err.Error()
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, err.Error()
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return false, "health check " + resp.Status
+	}
+	return true, ""
+}
+
+// candidates 返回本次请求的尝试顺序，健康节点优先
+func (p *UpstreamPool) candidates() []*upstreamTarget {
+	n := len(p.targets)
+	start := 0
+	if p.cfg.Strategy == StrategyRoundRobin {
+		start = int(p.next.Add(1)-1) % n
+	}
+	healthy := make([]*upstreamTarget, 0, n)
+	var unhealthy []*upstreamTarget
+	for i := 0; i < n; i++ {
+		t := p.targets[(start+i)%n]
+		if t.healthy.Load() {
+			healthy = append(healthy, t)
+		} else {
+			unhealthy = append(unhealthy, t)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// Do 依次尝试上游，send 负责基于给定的基础地址构造并发送请求。
+// 只有拨号失败、超时与 502/503/504 会标记节点不健康并切换上游，其它错误原样返回；
+// 非幂等请求只尝试一次，避免重复提交
+func (p *UpstreamPool) Do(ctx context.Context, method string, send func(baseURL string) (*http.Response, error)) (*http.Response, error) {
+	idempotent := isIdempotentMethod(method)
+	candidates := p.candidates()
+	var lastErr error
+	for i, t := range candidates {
+		if i > 0 {
+			t.failovers.Add(1)
+			p.logger.Printf("upstream failover: %s %s", method, t.url)
+		}
+		t.requests.Add(1)
+		resp, err := send(t.url)
+		if ctx.Err() != nil || (err != nil && !isFailoverError(err)) {
+			// 调用方取消或与上游无关的错误，不影响健康状态
+			return resp, err
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			t.setHealthy(true, p.logger, "")
+			return resp, nil
+		}
+
+		t.failures.Add(1)
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("upstream %s: %s", t.url, resp.Status)
+		}
+		t.setHealthy(false, p.logger, lastErr.Error())
+
+		if !idempotent || i == len(candidates)-1 {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	return nil, lastErr
+}
+
+// isFailoverError 判断错误是否表示上游不可用：拨号失败或超时
+func isFailoverError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusBadGateway ||
+		code == http.StatusServiceUnavailable ||
+		code == http.StatusGatewayTimeout
+}
+
+// isIdempotentMethod 参照 RFC 9110 9.2.2
+func isIdempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func statusServer(t *testing.T, status int) string {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, r.Host)
+	}))
+	t.Cleanup(s.Close)
+	return s.URL
+}
+
+// closedServer 返回一个已关闭的地址，连接会被拒绝
+func closedServer(t *testing.T) string {
+	t.Helper()
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+	return s.URL
+}
+
+func TestUpstreamPoolDo(t *testing.T) {
+	errBuild := errors.New("build request")
+
+	tests := []struct {
+		name        string
+		urls        func(t *testing.T) []string
+		method      string
+		send        func(base string) (*http.Response, error) // 为空时发送真实请求
+		cancel      bool
+		wantStatus  int
+		wantErr     error
+		wantAnyErr  bool
+		wantTarget  int    // 返回响应的上游下标
+		wantHealthy []bool // 调用后各上游的健康状态
+	}{
+		{
+			name:        "first healthy",
+			urls:        func(t *testing.T) []string { return []string{statusServer(t, 200), statusServer(t, 200)} },
+			method:      http.MethodGet,
+			wantStatus:  200,
+			wantTarget:  0,
+			wantHealthy: []bool{true, true},
+		},
+		{
+			name:        "dial error fails over",
+			urls:        func(t *testing.T) []string { return []string{closedServer(t), statusServer(t, 200)} },
+			method:      http.MethodGet,
+			wantStatus:  200,
+			wantTarget:  1,
+			wantHealthy: []bool{false, true},
+		},
+		{
+			name:        "retryable status fails over",
+			urls:        func(t *testing.T) []string { return []string{statusServer(t, 503), statusServer(t, 200)} },
+			method:      http.MethodGet,
+			wantStatus:  200,
+			wantTarget:  1,
+			wantHealthy: []bool{false, true},
+		},
+		{
+			name:        "last candidate returns retryable status",
+			urls:        func(t *testing.T) []string { return []string{statusServer(t, 502), statusServer(t, 504)} },
+			method:      http.MethodGet,
+			wantStatus:  504,
+			wantTarget:  1,
+			wantHealthy: []bool{false, false},
+		},
+		{
+			name:        "client error is not retried",
+			urls:        func(t *testing.T) []string { return []string{statusServer(t, 404), statusServer(t, 200)} },
+			method:      http.MethodGet,
+			wantStatus:  404,
+			wantTarget:  0,
+			wantHealthy: []bool{true, true},
+		},
+		{
+			name:        "non-idempotent request is sent once",
+			urls:        func(t *testing.T) []string { return []string{statusServer(t, 503), statusServer(t, 200)} },
+			method:      http.MethodPost,
+			wantStatus:  503,
+			wantTarget:  0,
+			wantHealthy: []bool{false, true},
+		},
+		{
+			name:        "non-transport error keeps health",
+			urls:        func(t *testing.T) []string { return []string{statusServer(t, 200), statusServer(t, 200)} },
+			method:      http.MethodGet,
+			send:        func(string) (*http.Response, error) { return nil, errBuild },
+			wantErr:     errBuild,
+			wantHealthy: []bool{true, true},
+		},
+		{
+			name:        "cancelled context keeps health",
+			urls:        func(t *testing.T) []string { return []string{statusServer(t, 200), statusServer(t, 200)} },
+			method:      http.MethodGet,
+			cancel:      true,
+			wantAnyErr:  true,
+			wantHealthy: []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls := tt.urls(t)
+			cli := &http.Client{}
+			pool, err := NewUpstreamPool(urls, UpstreamPoolConfig{}, cli, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			send := func(base string) (*http.Response, error) {
+				req, err := http.NewRequestWithContext(ctx, tt.method, base+"/pets", nil)
+				if err != nil {
+					return nil, err
+				}
+				return cli.Do(req)
+			}
+			if tt.send != nil {
+				send = tt.send
+			}
+
+			resp, err := pool.Do(ctx, tt.method, send)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantAnyErr:
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+				if got, want := "http://"+resp.Request.Host, urls[tt.wantTarget]; got != want {
+					t.Errorf("served by %s, want %s", got, want)
+				}
+			}
+
+			for i, want := range tt.wantHealthy {
+				if got := pool.targets[i].healthy.Load(); got != want {
+					t.Errorf("target %d healthy = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpstreamPoolRoundRobin(t *testing.T) {
+	urls := []string{statusServer(t, 200), statusServer(t, 200), statusServer(t, 200)}
+	pool, err := NewUpstreamPool(urls, UpstreamPoolConfig{Strategy: StrategyRoundRobin}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2*len(urls); i++ {
+		if got, want := pool.candidates()[0].url, urls[i%len(urls)]; got != want {
+			t.Errorf("request %d: first candidate = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestUpstreamPoolMetrics(t *testing.T) {
+	url := statusServer(t, 200)
+	a, err := NewUpstreamPool([]string{url}, UpstreamPoolConfig{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewUpstreamPool([]string{url}, UpstreamPoolConfig{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := a.Do(context.Background(), http.MethodGet, func(base string) (*http.Response, error) {
+		return http.Get(base)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// 同一地址的两个池各自计数
+	requests := func(p *UpstreamPool) string {
+		return p.Metrics().Get(url).(*expvar.Map).Get("requests").String()
+	}
+	if got := requests(a); got != "1" {
+		t.Errorf("pool a requests = %s, want 1", got)
+	}
+	if got := requests(b); got != "0" {
+		t.Errorf("pool b requests = %s, want 0", got)
+	}
+}
+
+func TestUpstreamPoolTransformPerAttempt(t *testing.T) {
+	var signed, host string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signed, host = r.Header.Get("X-Signed-Url"), "http://"+r.Host+r.URL.RequestURI()
+		_, _ = io.WriteString(w, `[]`)
+	}))
+	t.Cleanup(up.Close)
+
+	pool, err := NewUpstreamPool([]string{closedServer(t), up.URL}, UpstreamPoolConfig{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := NewConverter(loadTestDoc(t, testSpec),
+		WithUpstreamPool(pool),
+		WithTransforms([]Transform{{Operation: "listPets", Request: RequestTransform{Headers: map[string]string{"X-Signed-Url": "url"}}}}),
+	).Tools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := callTool(t, findTool(t, tools, "listPets"), map[string]any{"status": "sold"}); res.IsError {
+		t.Fatal(resultText(res))
+	}
+	// 故障转移后签名覆盖的是实际发送的地址
+	if signed == "" || signed != host {
+		t.Errorf("signed url = %q, want %q", signed, host)
+	}
+}
@@ -15,28 +15,21 @@
 	ServerVersion = "1.0.0"
 )
 
-func LoadOpenAPIDoc(src string) (*libopenapi.DocumentModel[v3high.Document], error) {
-	var data []byte
-	var err error
-	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
-		resp, err := http.Get(src)
-		if err != nil {
-			return nil, fmt.Errorf("fetch openapi url: %w", err)
-		}
-		defer resp.Body.Close()
+// LoadOpenAPIDoc 加载 OpenAPI 文档，overlays 按顺序应用后再构建模型
+func LoadOpenAPIDoc(src string, cli *http.Client, overlays ...string) (*libopenapi.DocumentModel[v3high.Document], error) {
+	data, err := readSource(src, cli)
+	if err != nil {
+		return nil, err
+	}
 
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return nil, fmt.Errorf("http error: %s", resp.Status)
-		}
-		data, err = io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("read openapi body: %w", err)
-		}
-	} else {
-		data, err = os.ReadFile(src)
+	for _, ovSrc := range overlays {
+		ov, err := readSource(ovSrc, cli)
 		if err != nil {
 			return nil, err
 		}
+		if data, err = ApplyOverlay(data, ov); err != nil {
+			return nil, fmt.Errorf("%s: %w", ovSrc, err)
+		}
 	}
 
 	doc, err := libopenapi.NewDocument(data)
@@ -51,6 +44,30 @@ func LoadOpenAPIDoc(src string) (*libopenapi.DocumentModel[v3high.Document], err
 	return model, nil
 }
 
+// readSource 读取本地文件或 http(s) 地址的内容
+func readSource(src string, cli *http.Client) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.ReadFile(src)
+	}
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	resp, err := cli.Get(src)
+	if err != nil {
+		return nil, fmt.Errorf("fetch openapi url: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("http error: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read openapi body: %w", err)
+	}
+	return data, nil
+}
+
 func LoadEnv(key, def string) string {
 	val := strings.TrimSpace(os.Getenv(key))
 	if val == "" {
@@ -58,3 +75,14 @@ func LoadEnv(key, def string) string {
 	}
 	return val
 }
+
+// LoadEnvList 读取逗号分隔的环境变量，忽略空项
+func LoadEnvList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(LoadEnv(key, ""), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	v3base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue 是文档中无法 (完整) 转换为工具的部分
+type Issue struct {
+	Severity string `json:"severity"`
+	Tool     string `json:"tool,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ValidateOpenAPI 检查通过过滤规则的每个操作，报告不支持或只部分支持的特性。
+// 级别为 error 的问题会使 AddToolFromOpenAPI 失败或生成无法调用的工具
+func ValidateOpenAPI(baseURL string, v3Model *libopenapi.DocumentModel[v3high.Document], opts ...Option) []Issue {
+	doc := v3Model.Model
+	o := newOptions(opts)
+
+	var issues []Issue
+	filter, err := o.filter.compile()
+	if err != nil {
+		return []Issue{{Severity: SeverityError, Message: err.Error()}}
+	}
+	targets, _ := linkTargets(doc, filter)
+	basicSchemes := collectBasicAuthSchemes(doc)
+	seen := map[string]string{}
+
+	_ = eachOperation(doc, func(path, method string, item *v3high.PathItem, op *v3high.Operation) error {
+		name := toolName(path, method, op)
+		if ok, _ := filter.match(path, method, name, op); !ok {
+			return nil
+		}
+		report := func(severity, format string, a ...any) {
+			issues = append(issues, Issue{Severity: severity, Tool: name, Method: method, Path: path,
+				Message: fmt.Sprintf(format, a...)})
+		}
+
+		if prev, ok := seen[name]; ok {
+			report(SeverityError, "tool name %s is already used by %s, set x-mcp-name to rename one", name, prev)
+		}
+		seen[name] = method + " " + path
+
+		params := mergeParameters(item.Parameters, op.Parameters)
+		for _, v := range parsePathTmpl(path) {
+			if !slices.ContainsFunc(params, func(p *v3high.Parameter) bool { return p != nil && p.In == "path" && p.Name == v }) {
+				report(SeverityError, "path variable {%s} is not declared as a path parameter", v)
+			}
+		}
+		for _, p := range params {
+			if p == nil || paramHidden(p) {
+				continue
+			}
+			if p.Schema == nil {
+				report(SeverityWarning, "parameter %s has no schema (content is not supported) and is not exposed", p.Name)
+				continue
+			}
+			s := p.Schema.Schema()
+			if s == nil {
+				continue
+			}
+			if composedWithoutType(s) {
+				report(SeverityWarning, "parameter %s uses allOf/oneOf/anyOf without a type and is exposed as a string", p.Name)
+			}
+			switch t := firstType(s.Type); {
+			case t == "array" || t == "object":
+				report(SeverityWarning, "%s parameter %s (in %s) is sent as a single formatted value", t, p.Name, p.In)
+			case p.Style != "" && p.Style != "form" && p.Style != "simple":
+				report(SeverityWarning, "parameter %s uses style %s, which is not supported", p.Name, p.Style)
+			}
+		}
+
+		if rb := op.RequestBody; rb != nil && rb.Content != nil {
+			if mt, ok := rb.Content.Get("application/json"); ok {
+				if mt.Schema != nil && composedWithoutType(mt.Schema.Schema()) {
+					report(SeverityWarning, "request body uses allOf/oneOf/anyOf without a type and is exposed as a string")
+				}
+			} else {
+				var types []string
+				for el := rb.Content.First(); el != nil; el = el.Next() {
+					types = append(types, el.Key())
+				}
+				if boolVal(rb.Required) {
+					report(SeverityWarning, "required request body %s is not supported, requests are sent without a body", strings.Join(types, ", "))
+				} else {
+					report(SeverityWarning, "request body %s is not supported, only application/json is sent", strings.Join(types, ", "))
+				}
+			}
+		}
+
+		security := op.Security
+		if len(security) == 0 {
+			security = doc.Security
+		}
+		for _, scheme := range securitySchemes(security) {
+			if _, ok := basicSchemes[scheme]; ok {
+				if o.authorization == "" {
+					report(SeverityError, "requires HTTP Basic authentication but no authorization is configured")
+				}
+				continue
+			}
+			typ := "undefined"
+			if doc.Components != nil && doc.Components.SecuritySchemes != nil {
+				if ss, ok := doc.Components.SecuritySchemes.Get(scheme); ok && ss != nil {
+					typ = ss.Type
+				}
+			}
+			report(SeverityWarning, "security scheme %s (%s) is not applied automatically, send credentials with upstream headers", scheme, typ)
+		}
+
+		if baseURL == "" && (o.pool == nil || len(op.Servers) > 0 || len(item.Servers) > 0) {
+			if _, err := operationBaseURL(doc, item, op, o); err != nil {
+				report(SeverityError, "%v", err)
+			}
+		}
+
+		if op.Callbacks != nil && op.Callbacks.Len() > 0 {
+			report(SeverityWarning, "callbacks are ignored")
+		}
+		if op.Responses != nil {
+			check := func(code string, r *v3high.Response) {
+				if r == nil || r.Links == nil {
+					return
+				}
+				for el := r.Links.First(); el != nil; el = el.Next() {
+					l := el.Value()
+					key := l.OperationId
+					if key == "" {
+						var ok bool
+						if key, ok = resolveOperationRef(l.OperationRef); !ok {
+							report(SeverityWarning, "link %s (%s): operationRef %q is not supported", el.Key(), code, l.OperationRef)
+							continue
+						}
+					}
+					if _, ok := targets[key]; !ok {
+						report(SeverityWarning, "link %s (%s): operation %s is not a tool", el.Key(), code, key)
+					}
+				}
+			}
+			if op.Responses.Codes != nil {
+				for el := op.Responses.Codes.First(); el != nil; el = el.Next() {
+					check(el.Key(), el.Value())
+				}
+			}
+			check("default", op.Responses.Default)
+		}
+		return nil
+	})
+
+	if len(seen) == 0 {
+		issues = append(issues, Issue{Severity: SeverityWarning, Message: "no operations are included"})
+	}
+	return issues
+}
+
+// composedWithoutType 判断 schema 是否只由组合关键字描述，这类 schema 会被当作字符串
+func composedWithoutType(s *v3base.Schema) bool {
+	return s != nil && len(s.Type) == 0 && (len(s.AllOf) > 0 || len(s.OneOf) > 0 || len(s.AnyOf) > 0)
+}
+
+// securitySchemes 返回安全要求中引用的方案名，空要求 ({}) 表示可匿名访问
+func securitySchemes(reqs []*v3base.SecurityRequirement) []string {
+	var out []string
+	for _, sr := range reqs {
+		if sr == nil || sr.Requirements == nil {
+			continue
+		}
+		for el := sr.Requirements.First(); el != nil; el = el.Next() {
+			if !slices.Contains(out, el.Key()) {
+				out = append(out, el.Key())
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,383 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow 定义一个由多个操作顺序组成的工具，在一次调用中由服务端执行。
+// 步骤参数中的 ${{ expr }} 在运行时求值，可以引用 args (工具参数) 与 steps.<id> (之前步骤的结果)
+type Workflow struct {
+	Name        string             `yaml:"name" json:"name"`
+	Description string             `yaml:"description,omitempty" json:"description,omitempty"`
+	Arguments   []WorkflowArgument `yaml:"arguments,omitempty" json:"arguments,omitempty"`
+	Steps       []WorkflowStep     `yaml:"steps" json:"steps"`
+	Output      string             `yaml:"output,omitempty" json:"output,omitempty"` // 结果表达式，默认返回全部步骤结果
+}
+
+type WorkflowArgument struct {
+	Name        string `yaml:"name" json:"name"`
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"` // string (默认)、number、integer、boolean、object、array
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+type WorkflowStep struct {
+	ID        string         `yaml:"id" json:"id"`
+	Operation string         `yaml:"operation" json:"operation"`
+	If        string         `yaml:"if,omitempty" json:"if,omitempty"` // 为假时跳过该步骤
+	Arguments map[string]any `yaml:"arguments,omitempty" json:"arguments,omitempty"`
+	OnError   string         `yaml:"onError,omitempty" json:"onError,omitempty"` // fail (默认) 或 continue
+}
+
+const (
+	OnErrorFail     = "fail"
+	OnErrorContinue = "continue"
+)
+
+// LoadWorkflows 读取 YAML 或 JSON 格式的 workflow 列表
+func LoadWorkflows(path string) ([]Workflow, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Workflows []Workflow `yaml:"workflows"`
+	}
+	if err := yaml.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("parse workflows %s: %w", path, err)
+	}
+	return out.Workflows, nil
+}
+
+var exprRe = regexp.MustCompile(`\$\{\{\s*(.*?)\s*}}`)
+
+// stepResult 是步骤执行结果，可在后续表达式中通过 steps.<id> 引用
+type stepResult struct {
+	Status  int    `json:"status,omitempty"`
+	Body    any    `json:"body,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+func (r stepResult) env() map[string]any {
+	return map[string]any{"status": r.Status, "body": r.Body, "error": r.Error, "skipped": r.Skipped}
+}
+
+type workflowRunner struct {
+	wf       Workflow
+	tools    map[string]operationTool
+	programs map[string]*vm.Program
+}
+
+// compile 预编译全部表达式，启动时即可发现语法错误与未知操作
+func (r *workflowRunner) compile() error {
+	if r.wf.Name == "" {
+		return fmt.Errorf("workflow without name")
+	}
+	if len(r.wf.Steps) == 0 {
+		return fmt.Errorf("workflow %s: no steps", r.wf.Name)
+	}
+	add := func(code string) error {
+		if _, ok := r.programs[code]; ok {
+			return nil
+		}
+		p, err := expr.Compile(code)
+		if err != nil {
+			return fmt.Errorf("workflow %s: %w", r.wf.Name, err)
+		}
+		r.programs[code] = p
+		return nil
+	}
+	var walk func(v any) error
+	walk = func(v any) error {
+		switch vv := v.(type) {
+		case string:
+			for _, m := range exprRe.FindAllStringSubmatch(vv, -1) {
+				if err := add(m[1]); err != nil {
+					return err
+				}
+			}
+		case map[string]any:
+			for _, x := range vv {
+				if err := walk(x); err != nil {
+					return err
+				}
+			}
+		case []any:
+			for _, x := range vv {
+				if err := walk(x); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for i, st := range r.wf.Steps {
+		if st.ID == "" {
+			return fmt.Errorf("workflow %s: step %d without id", r.wf.Name, i+1)
+		}
+		if seen[st.ID] {
+			return fmt.Errorf("workflow %s: duplicate step id %q", r.wf.Name, st.ID)
+		}
+		seen[st.ID] = true
+		if _, ok := r.tools[st.Operation]; !ok {
+			return fmt.Errorf("workflow %s: step %s: unknown operation %q", r.wf.Name, st.ID, st.Operation)
+		}
+		switch st.OnError {
+		case "", OnErrorFail, OnErrorContinue:
+		default:
+			return fmt.Errorf("workflow %s: step %s: onError must be %s or %s", r.wf.Name, st.ID, OnErrorFail, OnErrorContinue)
+		}
+		if st.If != "" {
+			if err := add(st.If); err != nil {
+				return err
+			}
+		}
+		if err := walk(st.Arguments); err != nil {
+			return err
+		}
+	}
+	if r.wf.Output != "" {
+		return add(r.wf.Output)
+	}
+	return nil
+}
+
+func (r *workflowRunner) eval(code string, env map[string]any) (any, error) {
+	return expr.Run(r.programs[code], env)
+}
+
+// render 对参数中的表达式求值。整个字符串为单个表达式时保留结果类型，否则按字符串插值
+func (r *workflowRunner) render(v any, env map[string]any) (any, error) {
+	switch vv := v.(type) {
+	case string:
+		if m := exprRe.FindStringSubmatch(vv); m != nil && m[0] == vv {
+			return r.eval(m[1], env)
+		}
+		var err error
+		out := exprRe.ReplaceAllStringFunc(vv, func(s string) string {
+			val, e := r.eval(exprRe.FindStringSubmatch(s)[1], env)
+			if e != nil {
+				err = e
+				return ""
+			}
+			return formatValue(val)
+		})
+		return out, err
+	case map[string]any:
+		out := make(map[string]any, len(vv))
+		for k, x := range vv {
+			rx, err := r.render(x, env)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rx
+		}
+		return out, nil
+	case []any:
+		out := make([]any, 0, len(vv))
+		for _, x := range vv {
+			rx, err := r.render(x, env)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, rx)
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// exec 调用操作并返回状态码与响应体，参数默认值、dry-run、mock 与确认的处理与工具调用相同。
+// dry-run 时响应体为请求描述
+func (t operationTool) exec(ctx context.Context, args, reserved map[string]any) (int, []byte, error) {
+	var raw map[string]any
+	if t.caller.isDryRun(reserved) {
+		// 不发送请求，依赖前面步骤响应的参数允许缺失
+		raw = applyParamValues(args, t.caller.o.paramDefaults, t.caller.o.paramFixed)
+	} else {
+		var res *mcp.CallToolResult
+		if raw, res = t.caller.resolveArgs(ctx, t.name, args); res != nil {
+			return 0, nil, errors.New(resultText(res))
+		}
+	}
+	p, err := t.caller.prepare(raw)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, res, err := t.caller.call(ctx, p, reserved)
+	switch {
+	case err != nil:
+		return 0, nil, err
+	case res != nil && res.IsError:
+		return 0, nil, errors.New(resultText(res))
+	case res != nil && res.StructuredContent != nil:
+		body, err := json.Marshal(res.StructuredContent)
+		return 0, body, err
+	case res != nil:
+		return http.StatusOK, []byte(resultText(res)), nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+func resultText(res *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range res.Content {
+		if tc, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, tc.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func decodeBody(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	return v
+}
+
+func (r *workflowRunner) run(ctx context.Context, args map[string]any) *mcp.CallToolResult {
+	reserved, args := popReservedArgs(args, nil)
+	steps := map[string]any{}
+	results := map[string]stepResult{}
+	env := map[string]any{"args": args, "steps": steps}
+
+	fail := func(format string, a ...any) *mcp.CallToolResult {
+		msg := fmt.Sprintf(format, a...)
+		res := mcp.NewToolResultStructured(map[string]any{"error": msg, "steps": results}, msg)
+		res.IsError = true
+		return res
+	}
+
+	for _, st := range r.wf.Steps {
+		if st.If != "" {
+			ok, err := r.eval(st.If, env)
+			if err != nil {
+				return fail("step %s: if: %v", st.ID, err)
+			}
+			if b, _ := ok.(bool); !b {
+				results[st.ID] = stepResult{Skipped: true}
+				steps[st.ID] = results[st.ID].env()
+				continue
+			}
+		}
+
+		rendered, err := r.render(st.Arguments, env)
+		if err != nil {
+			return fail("step %s: arguments: %v", st.ID, err)
+		}
+		stepArgs, _ := rendered.(map[string]any)
+		stepReserved, stepArgs := popReservedArgs(stepArgs, r.tools[st.Operation].caller.paramIn)
+		if reservedBool(reserved, ArgDryRun) {
+			stepReserved[ArgDryRun] = true
+		}
+
+		status, body, err := r.tools[st.Operation].exec(ctx, stepArgs, stepReserved)
+		res := stepResult{Status: status, Body: decodeBody(body)}
+		switch {
+		case err != nil:
+			res.Error = err.Error()
+		case status >= http.StatusBadRequest:
+			res.Error = fmt.Sprintf("upstream returned %d", status)
+		}
+		results[st.ID] = res
+		steps[st.ID] = res.env()
+
+		if res.Error != "" && st.OnError != OnErrorContinue {
+			return fail("step %s (%s) failed: %s", st.ID, st.Operation, res.Error)
+		}
+	}
+
+	var out any = results
+	if r.wf.Output != "" {
+		v, err := r.eval(r.wf.Output, env)
+		if err != nil {
+			return fail("output: %v", err)
+		}
+		out = v
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return mcp.NewToolResultText(string(b))
+}
+
+func workflowTool(wf Workflow) mcp.Tool {
+	desc := wf.Description
+	if desc == "" {
+		ops := make([]string, 0, len(wf.Steps))
+		for _, st := range wf.Steps {
+			ops = append(ops, st.Operation)
+		}
+		desc = "Runs " + strings.Join(ops, ", then ")
+	}
+	opts := []mcp.ToolOption{mcp.WithDescription(desc)}
+	for _, a := range wf.Arguments {
+		popts := []mcp.PropertyOption{mcp.Description(a.Description)}
+		if a.Required {
+			popts = append(popts, mcp.Required())
+		}
+		switch a.Type {
+		case "number", "integer":
+			opts = append(opts, mcp.WithNumber(a.Name, popts...))
+		case "boolean":
+			opts = append(opts, mcp.WithBoolean(a.Name, popts...))
+		case "object":
+			opts = append(opts, mcp.WithObject(a.Name, popts...))
+		case "array":
+			opts = append(opts, mcp.WithArray(a.Name, popts...))
+		default:
+			opts = append(opts, mcp.WithString(a.Name, popts...))
+		}
+	}
+	opts = append(opts, mcp.WithBoolean(ArgDryRun,
+		mcp.Description("Return the request each step would send instead of calling the upstream API")))
+	return mcp.NewTool(wf.Name, opts...)
+}
+
+// addWorkflowTools 将 workflow 注册为工具，步骤只能引用已包含的操作
+func addWorkflowTools(mcpServer ToolRegistry, workflows []Workflow, tools []operationTool) error {
+	byName := make(map[string]operationTool, len(tools))
+	for _, t := range tools {
+		byName[t.name] = t
+	}
+	for _, wf := range workflows {
+		r := &workflowRunner{wf: wf, tools: byName, programs: map[string]*vm.Program{}}
+		if err := r.compile(); err != nil {
+			return err
+		}
+		mcpServer.AddTool(workflowTool(wf), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := request.GetArguments()
+			for _, a := range wf.Arguments {
+				if v, ok := args[a.Name]; a.Required && (!ok || v == nil) {
+					return mcp.NewToolResultError("missing required argument: " + a.Name), nil
+				}
+			}
+			return r.run(ctx, args), nil
+		})
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pb33f/libopenapi"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info: {title: Pets, version: 1.0.0}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      parameters:
+        - {name: status, in: query, schema: {type: string}}
+      responses:
+        '200': {description: ok}
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+      responses:
+        '201':
+          description: created
+          content:
+            application/json:
+              example: {id: 1, name: Rex}
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      tags: [pets]
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer}}
+      responses:
+        '200': {description: ok}
+`
+
+func loadTestDoc(t *testing.T, spec string) *libopenapi.DocumentModel[v3high.Document] {
+	t.Helper()
+	doc, err := libopenapi.NewDocument([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, errs := doc.BuildV3Model()
+	if len(errs) > 0 {
+		t.Fatal(errs[0])
+	}
+	return model
+}
+
+// recordingUpstream 记录收到的请求，创建宠物时返回较大的数字 id 与签发的 token
+func recordingUpstream(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":1234567,"name":"Rex","token":"issued-token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(s.Close)
+	return s, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+func findTool(t *testing.T, tools []server.ServerTool, name string) server.ServerTool {
+	t.Helper()
+	for _, st := range tools {
+		if st.Tool.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("tool %s not registered", name)
+	return server.ServerTool{}
+}
+
+func callTool(t *testing.T, st server.ServerTool, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	req := mcp.CallToolRequest{}
+	req.Params.Name = st.Tool.Name
+	req.Params.Arguments = args
+	res, err := st.Handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestWorkflowRun(t *testing.T) {
+	workflow := Workflow{
+		Name: "adopt",
+		Steps: []WorkflowStep{
+			{ID: "create", Operation: "createPet", Arguments: map[string]any{"body": map[string]any{"name": "Rex"}}},
+			{ID: "get", Operation: "getPet", Arguments: map[string]any{"petId": "${{ steps.create.body.id }}"}},
+			{ID: "list", Operation: "listPets", Arguments: map[string]any{"status": "after-${{ steps.create.body.id }}"}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		args         map[string]any
+		wantRequests []string
+		wantOutput   string // 结果中应包含的内容
+	}{
+		{
+			name:         "float ids keep integer form",
+			wantRequests: []string{"POST /pets", "GET /pets/1234567", "GET /pets?status=after-1234567"},
+			wantOutput:   `"id":1234567`,
+		},
+		{
+			name:       "global dry-run sends nothing",
+			opts:       []Option{WithDryRun(true)},
+			wantOutput: `"dryRun":true`,
+		},
+		{
+			name:       "workflow dry-run argument sends nothing",
+			args:       map[string]any{ArgDryRun: true},
+			wantOutput: `/pets/%7BpetId%7D`,
+		},
+		{
+			name:       "mock sends nothing",
+			opts:       []Option{WithMockUpstream(true)},
+			wantOutput: `"status":200`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, requests := recordingUpstream(t)
+			opts := append([]Option{WithBaseURL(up.URL), WithWorkflows([]Workflow{workflow})}, tt.opts...)
+			tools, err := NewConverter(loadTestDoc(t, testSpec), opts...).Tools()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res := callTool(t, findTool(t, tools, "adopt"), tt.args)
+			text := resultText(res)
+			if res.IsError {
+				t.Fatalf("workflow failed: %s", text)
+			}
+			if !strings.Contains(text, tt.wantOutput) {
+				t.Errorf("output %s does not contain %s", text, tt.wantOutput)
+			}
+			if got := requests(); !slices.Equal(got, tt.wantRequests) {
+				t.Errorf("upstream requests = %q, want %q", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestWorkflowStepFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		onError   string
+		wantError bool
+	}{
+		{name: "fail stops the workflow", onError: OnErrorFail, wantError: true},
+		{name: "continue runs later steps", onError: OnErrorContinue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, _ := recordingUpstream(t)
+			wf := Workflow{
+				Name: "lookup",
+				Steps: []WorkflowStep{
+					// 缺少必填参数且无法 elicitation
+					{ID: "get", Operation: "getPet", OnError: tt.onError},
+					{ID: "list", Operation: "listPets"},
+				},
+			}
+			tools, err := NewConverter(loadTestDoc(t, testSpec), WithBaseURL(up.URL), WithWorkflows([]Workflow{wf})).Tools()
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := callTool(t, findTool(t, tools, "lookup"), nil)
+			if res.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v: %s", res.IsError, tt.wantError, resultText(res))
+			}
+			if !tt.wantError {
+				var out map[string]stepResult
+				if err := json.Unmarshal([]byte(resultText(res)), &out); err != nil {
+					t.Fatal(err)
+				}
+				if out["get"].Error == "" || out["list"].Status != http.StatusOK {
+					t.Errorf("unexpected step results %+v", out)
+				}
+			}
+		})
+	}
+}
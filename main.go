@@ -2,42 +2,151 @@
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"github.com/constellation39/openapi-to-mcp/core"
 	"github.com/constellation39/openapi-to-mcp/core/session"
 	"github.com/joho/godotenv"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/pb33f/libopenapi"
+	v3high "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"io"
+	"io/fs"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
+// upstreamVars 在 /debug/vars 中发布上游池的计数
+var upstreamVars = expvar.NewMap("upstreams")
+
 func init() {
 	_ = godotenv.Load()
 }
 
 func main() {
-	transport := strings.ToLower(core.LoadEnv("MCP_TRANSPORT", "stdio"))
-	if err := start(transport); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func start(transport string) error {
-	var logger *log.Logger
-	logOutput := core.LoadEnv("LOG_OUTPUT", "false")
-	if logOutput == "false" {
-		logger = log.New(io.Discard, "[MCP] ", log.LstdFlags|log.Lshortfile)
-	} else {
-		logger = log.New(os.Stdout, "[MCP] ", log.LstdFlags|log.Lshortfile)
+// app 持有各子命令共用的 HTTP Client 与组件
+type app struct {
+	cfg         *core.Config
+	logger      *log.Logger
+	httpClient  *http.Client // 文档加载与健康检查
+	toolClient  *http.Client // 工具调用，可能经过录制或回放
+	completer   *core.Completer
+	rateLimiter *core.RateLimitMiddleware
+}
+
+// newApp 根据配置创建 app，LogOutput 开启时日志写入 logOut
+func newApp(cfg *core.Config, logOut io.Writer) (*app, error) {
+	if !cfg.Server.LogOutput {
+		logOut = io.Discard
+	}
+	a := &app{
+		cfg:       cfg,
+		logger:    log.New(logOut, "[MCP] ", log.LstdFlags|log.Lshortfile),
+		completer: core.NewCompleter(),
+	}
+
+	upstreamTransport, err := core.NewHTTPTransport(cfg.Upstream.TransportConfig)
+	if err != nil {
+		return nil, err
+	}
+	a.httpClient = &http.Client{Transport: upstreamTransport, Timeout: 60 * time.Second}
+
+	toolTransport, err := newToolTransport(cfg.Upstream, upstreamTransport, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	a.toolClient = &http.Client{Transport: toolTransport, Timeout: 60 * time.Second}
+
+	if cfg.Limits.RatePerSecond > 0 {
+		a.rateLimiter = core.NewRateLimitMiddleware(cfg.Limits.RatePerSecond, 1)
+	}
+	return a, nil
+}
+
+// loadSpec 加载 OpenAPI 文档并构建工具选项，配置了上游池时同时启动健康检查
+func (a *app) loadSpec() (*libopenapi.DocumentModel[v3high.Document], []core.Option, error) {
+	cfg := a.cfg
+	if cfg.OpenAPI.Src == "" {
+		return nil, nil, fmt.Errorf("no OpenAPI document configured, set openapi.src or OPENAPI_SRC")
+	}
+	doc, err := core.LoadOpenAPIDoc(cfg.OpenAPI.Src, a.httpClient, cfg.OpenAPI.Overlays...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("openapi load error: %w", err)
+	}
+
+	batchConcurrency := 0
+	if cfg.Tools.Batch.Enabled {
+		batchConcurrency = cfg.Tools.Batch.Concurrency
 	}
+	toolOpts := []core.Option{
+		core.WithHTTPClient(a.toolClient),
+		core.WithSpecURL(cfg.OpenAPI.Src),
+		core.WithServer(cfg.OpenAPI.Server),
+		core.WithServerVariables(cfg.OpenAPI.ServerVariables),
+		core.WithLogger(a.logger),
+		core.WithCookies(cfg.Upstream.Cookies),
+		core.WithAuthorization(cfg.Auth.Authorization),
+		core.WithToolFilter(cfg.Tools.Filter),
+		core.WithDestructiveMethods(cfg.Tools.DestructiveMethods...),
+		core.WithDryRun(cfg.Tools.DryRun),
+		core.WithMockUpstream(cfg.Tools.Mock),
+		core.WithResourceTemplates(cfg.OpenAPI.ResourceTemplates),
+		core.WithCompleter(a.completer),
+		core.WithToolMode(cfg.Tools.Mode),
+		core.WithLinks(cfg.OpenAPI.Links),
+		core.WithBatchTool(batchConcurrency),
+		core.WithRateLimiter(a.rateLimiter),
+		core.WithConfirmPolicy(cfg.Tools.Confirm),
+	}
+
+	workflows := cfg.Workflows
+	if path := cfg.WorkflowsFile; path != "" {
+		loaded, err := core.LoadWorkflows(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		workflows = append(workflows, loaded...)
+	}
+	toolOpts = append(toolOpts, core.WithWorkflows(workflows))
+
+	transforms := cfg.Transforms
+	if path := cfg.TransformsFile; path != "" {
+		loaded, err := core.LoadTransforms(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		transforms = append(transforms, loaded...)
+	}
+	toolOpts = append(toolOpts, core.WithTransforms(transforms))
+
+	pool, err := newUpstreamPool(cfg.Upstream, doc.Model, a.httpClient, a.logger, toolOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pool != nil {
+		a.logger.Printf("upstream pool: %v", pool.URLs())
+		go pool.Run(context.Background())
+		pool.Metrics().Do(func(kv expvar.KeyValue) { upstreamVars.Set(kv.Key, kv.Value) })
+		toolOpts = append(toolOpts, core.WithUpstreamPool(pool))
+	}
+	return doc, toolOpts, nil
+}
 
+// newServer 创建 MCP 服务并注册文档生成的工具、资源与提示，未配置文档时服务为空
+func (a *app) newServer() (*server.MCPServer, error) {
+	cfg, logger, completer := a.cfg, a.logger, a.completer
 	sessionMgr := session.Instance()
 
 	hooks := &server.Hooks{}
@@ -47,9 +156,10 @@ func start(transport string) error {
 	})
 	hooks.AddOnUnregisterSession(func(ctx context.Context, s server.ClientSession) {
 		sessionMgr.RemoveSession(s.SessionID())
+		completer.ForgetSession(s.SessionID())
 		logger.Printf("<<< session end   %s", s.SessionID())
 	})
-	hooks.AddAfterCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest, result *mcp.CallToolResult) {
+	hooks.AddAfterCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest, result any) {
 		logger.Printf("afterCallTool: %v, %v, %v\n", id, message, result)
 	})
 	hooks.AddBeforeCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest) {
@@ -62,17 +172,15 @@ func start(transport string) error {
 		server.WithToolCapabilities(false),
 		server.WithResourceCapabilities(false, true),
 		server.WithPromptCapabilities(true),
+		server.WithElicitation(),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(completer),
+		server.WithResourceCompletionProvider(completer),
 		server.WithRecovery(),
 		server.WithLogging(),
 	}
-
-	rpsStr := core.LoadEnv("RATE_LIMIT_PER_SECOND", "")
-	if rpsStr != "" {
-		rateLimit, err := strconv.ParseFloat(rpsStr, 64)
-		if err != nil {
-			return err
-		}
-		server.WithToolHandlerMiddleware(core.NewRateLimitMiddleware(rateLimit, 1).ToolMiddleware)
+	if a.rateLimiter != nil {
+		serverOptions = append(serverOptions, server.WithToolHandlerMiddleware(a.rateLimiter.ToolMiddleware))
 	}
 
 	mcpServer := server.NewMCPServer(
@@ -81,29 +189,62 @@ func start(transport string) error {
 		serverOptions...,
 	)
 
-	extraHeadersStr := core.LoadEnv("EXTRA_HEADERS", "")
-	hdr := map[string]string{}
-	if extraHeadersStr != "" {
-		if err := json.Unmarshal([]byte(extraHeadersStr), &hdr); err != nil {
-			logger.Printf("Failed to parse EXTRA_HEADERS: %v", err)
-		}
+	if cfg.OpenAPI.Src == "" {
+		return mcpServer, nil
+	}
+	doc, toolOpts, err := a.loadSpec()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.register(mcpServer, doc, toolOpts); err != nil {
+		return nil, err
 	}
+	return mcpServer, nil
+}
 
-	src := core.LoadEnv("OPENAPI_SRC", "")
-	if src != "" {
-		doc, err := core.LoadOpenAPIDoc(src)
-		if err != nil {
-			logger.Fatalf("openapi load error: %v", err)
+// register 向服务注册文档生成的工具、资源与提示
+func (a *app) register(mcpServer *server.MCPServer, doc *libopenapi.DocumentModel[v3high.Document], toolOpts []core.Option) error {
+	cfg := a.cfg
+	err := core.AddToolFromOpenAPI(
+		mcpServer,
+		cfg.OpenAPI.BaseURL,
+		cfg.Upstream.Headers,
+		doc,
+		toolOpts...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if cfg.OpenAPI.Resources {
+		if err := core.AddResourcesFromOpenAPI(mcpServer, doc, toolOpts...); err != nil {
+			return err
 		}
-		err = core.AddToolFromOpenAPI(
-			mcpServer,
-			core.LoadEnv("OPENAPI_BASE_URL", ""),
-			hdr,
-			doc,
-		)
+	}
+
+	prompts := cfg.Prompts
+	if path := cfg.PromptsFile; path != "" {
+		loaded, err := core.LoadPromptTemplates(path)
 		if err != nil {
-			logger.Fatal(err)
+			return err
 		}
+		prompts = append(prompts, loaded...)
+	}
+	return core.AddPromptsFromOpenAPI(mcpServer, doc, cfg.OpenAPI.Prompts, prompts, toolOpts...)
+}
+
+// serve 按配置的传输方式运行 MCP 服务
+func (a *app) serve() error {
+	cfg, logger := a.cfg, a.logger
+	mcpServer, err := a.newServer()
+	if err != nil {
+		return err
+	}
+
+	if addr := cfg.Server.MetricsAddr; addr != "" {
+		go func() {
+			logger.Printf("metrics: %v", http.ListenAndServe(addr, expvar.Handler()))
+		}()
 	}
 
 	stop := make(chan os.Signal, 1)
@@ -114,18 +255,55 @@ func start(transport string) error {
 		os.Exit(0)
 	}()
 
-	switch transport {
+	switch transport := strings.ToLower(cfg.Server.Transport); transport {
 	case "stdio":
 		return server.ServeStdio(mcpServer)
 	case "sse":
-		baseURL := core.LoadEnv("MCP_BASE_URL", ":8080")
 		httpServer := server.NewSSEServer(mcpServer)
-		return httpServer.Start(baseURL)
+		return httpServer.Start(cfg.Server.Addr)
 	case "stream":
-		baseURL := core.LoadEnv("MCP_BASE_URL", ":8080")
 		httpServer := server.NewStreamableHTTPServer(mcpServer, server.WithStateLess(true))
-		return httpServer.Start(baseURL)
+		return httpServer.Start(cfg.Server.Addr)
 	default:
-		return fmt.Errorf("unknown MCP_TRANSPORT=%s", transport)
+		return fmt.Errorf("unknown transport %s", transport)
+	}
+}
+
+// newToolTransport 按 upstream.record / upstream.replay 包装工具调用使用的 Transport，
+// 文档加载与健康检查不经过它
+func newToolTransport(cfg core.UpstreamConfig, rt http.RoundTripper, logger *log.Logger) (http.RoundTripper, error) {
+	switch {
+	case cfg.Record != "":
+		rec, err := core.NewCassetteRecorder(cfg.Record, rt, cfg.RecordOverwrite, logger)
+		if errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("%w, remove it or set upstream.recordOverwrite", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return rec, nil
+	case cfg.Replay != "":
+		rep, err := core.NewCassetteReplayer(cfg.Replay)
+		if err != nil {
+			return nil, err
+		}
+		return rep, nil
+	}
+	return rt, nil
+}
+
+// newUpstreamPool 根据 upstream.servers 构建上游池，未配置时返回 nil。
+// servers 为 ["openapi"] 表示使用文档中声明的全部 servers
+func newUpstreamPool(cfg core.UpstreamConfig, doc v3high.Document, cli *http.Client, logger *log.Logger, opts []core.Option) (*core.UpstreamPool, error) {
+	urls := cfg.Servers
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	if len(urls) == 1 && urls[0] == "openapi" {
+		var err error
+		if urls, err = core.ServerURLs(doc, opts...); err != nil {
+			return nil, err
+		}
 	}
+	return core.NewUpstreamPool(urls, cfg.UpstreamPoolConfig, cli, logger)
 }